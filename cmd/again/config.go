@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/msaeedsaeedi/again/internal/domain"
+	"github.com/msaeedsaeedi/again/internal/infra"
+	"github.com/spf13/cobra"
+)
+
+// Flags that only make sense on the command line.
+var cliOnlyFlags = []string{"config", "profile", "help", "version"}
+
+// untilFlags are mutually exclusive, so setting one from a later layer clears the others.
+var untilFlags = []string{"until-fail", "until-success", "until-streak"}
+
+type configValue struct {
+	value  string
+	source domain.ConfigSource
+}
+
+// configFiles lists the files to load, from lowest to highest precedence: the user-level
+// file, then the project file found from the working directory or the one given by --config.
+func configFiles(opts *options) ([]string, error) {
+	var paths []string
+	if path := infra.UserConfigPath(); path != "" {
+		paths = append(paths, path)
+	}
+
+	if opts.configPath != "" {
+		return append(paths, opts.configPath), nil
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	project, err := infra.FindProjectConfig(wd)
+	if err != nil {
+		return nil, err
+	}
+	if project != "" && !slices.Contains(paths, project) {
+		paths = append(paths, project)
+	}
+	return paths, nil
+}
+
+// loadConfig applies configuration file settings to every flag that was not given on
+// the command line, and returns where each applied setting came from. The top-level
+// settings of all files are applied before the selected profile.
+func loadConfig(cmd *cobra.Command, opts *options) (domain.ConfigSources, error) {
+	paths, err := configFiles(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make(map[string]configValue)
+	var order []string
+	set := func(path string, setting infra.ConfigSetting) error {
+		if slices.Contains(cliOnlyFlags, setting.Key) || cmd.Flags().Lookup(setting.Key) == nil {
+			return fmt.Errorf("%s:%d: unknown option %q", path, setting.Line, setting.Key)
+		}
+		if slices.Contains(untilFlags, setting.Key) {
+			for _, key := range untilFlags {
+				delete(merged, key)
+			}
+		}
+		if _, ok := merged[setting.Key]; !ok {
+			order = append(order, setting.Key)
+		}
+		merged[setting.Key] = configValue{
+			value:  setting.Value,
+			source: domain.ConfigSource{File: path, Line: setting.Line},
+		}
+		return nil
+	}
+
+	files := make([]*infra.ConfigFile, 0, len(paths))
+	for _, path := range paths {
+		file, err := infra.LoadConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+		for _, setting := range file.Settings {
+			if err := set(path, setting); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// The selected profile overrides the top-level settings of every file, its
+	// definitions in the project file overriding those in the user file
+	profileFound := false
+	for _, file := range files {
+		profile, ok := file.Profiles[opts.profile]
+		if opts.profile == "" || !ok {
+			continue
+		}
+		profileFound = true
+		for _, setting := range profile {
+			if err := set(file.Path, setting); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if opts.profile != "" && !profileFound {
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("profile %q requested but no configuration file was found", opts.profile)
+		}
+		return nil, fmt.Errorf("profile %q is not defined in %v", opts.profile, paths)
+	}
+
+	cliUntil := slices.ContainsFunc(untilFlags, cmd.Flags().Changed)
+	sources := make(domain.ConfigSources)
+	for _, key := range order {
+		v, ok := merged[key]
+		if !ok || cmd.Flags().Changed(key) {
+			continue
+		}
+		if cliUntil && slices.Contains(untilFlags, key) {
+			continue
+		}
+
+		if err := cmd.Flags().Lookup(key).Value.Set(v.value); err != nil {
+			return nil, fmt.Errorf("%s: invalid value %q for %s: %v", v.source, v.value, key, err)
+		}
+		sources[key] = v.source
+	}
+
+	return sources, nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	const user = `
+times: 2
+timeout: 1s
+profiles:
+  ci:
+    parallel: 3
+    timeout: 7s
+`
+	const project = `
+times: 4
+timeout: 2s
+max-output: 1MB
+profiles:
+  ci:
+    parallel: 5
+  soak:
+    until-streak: 10
+`
+
+	tests := []struct {
+		name  string
+		args  []string
+		want  map[string]string // Flag values after loading
+		files map[string]string // Flags whose value comes from a file, and the file
+		err   string
+	}{
+		{
+			name:  "project overrides user",
+			want:  map[string]string{"times": "4", "timeout": "2s", "parallel": "1", "max-output": "1MB"},
+			files: map[string]string{"times": "project", "timeout": "project", "max-output": "project"},
+		},
+		{
+			name:  "profile overrides the top-level settings of both files",
+			args:  []string{"--profile", "ci"},
+			want:  map[string]string{"times": "4", "timeout": "7s", "parallel": "5"},
+			files: map[string]string{"times": "project", "timeout": "user", "parallel": "project", "max-output": "project"},
+		},
+		{
+			name:  "command line overrides everything",
+			args:  []string{"--profile", "ci", "--timeout", "9s", "-p", "8"},
+			want:  map[string]string{"times": "4", "timeout": "9s", "parallel": "8"},
+			files: map[string]string{"times": "project", "max-output": "project"},
+		},
+		{
+			name: "until flag on the command line replaces a configured one",
+			args: []string{"--profile", "soak", "--until-fail"},
+			want: map[string]string{"until-streak": "0", "until-fail": "true"},
+		},
+		{
+			name: "unknown profile",
+			args: []string{"--profile", "nightly"},
+			err:  `profile "nightly" is not defined`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configHome := t.TempDir()
+			t.Setenv("XDG_CONFIG_HOME", configHome)
+			t.Setenv("HOME", configHome)
+			userPath := filepath.Join(configHome, "again", "config.yaml")
+			projectPath := filepath.Join(t.TempDir(), ".again.yaml")
+			writeFile(t, userPath, user)
+			writeFile(t, projectPath, project)
+
+			opts := &options{}
+			cmd := newRootCmd(opts)
+			if err := cmd.ParseFlags(append([]string{"--config", projectPath}, tt.args...)); err != nil {
+				t.Fatal(err)
+			}
+
+			sources, err := loadConfig(cmd, opts)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for flag, want := range tt.want {
+				if got := cmd.Flags().Lookup(flag).Value.String(); got != want {
+					t.Errorf("--%s = %s, want %s", flag, got, want)
+				}
+			}
+			paths := map[string]string{"user": userPath, "project": projectPath}
+			for flag, file := range tt.files {
+				if got := sources[flag].File; got != paths[file] {
+					t.Errorf("--%s set from %q, want the %s file", flag, got, file)
+				}
+			}
+			for flag := range sources {
+				if _, ok := tt.files[flag]; !ok && tt.files != nil {
+					t.Errorf("--%s unexpectedly set from %s", flag, sources[flag])
+				}
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/msaeedsaeedi/again/internal/app"
+	"github.com/msaeedsaeedi/again/internal/domain"
+	"github.com/spf13/cobra"
+)
+
+// errOutputsDiffer makes again tools diff exit with status 1, as diff(1) does when its inputs differ.
+var errOutputsDiffer = errors.New("outputs differ")
+
+func newDiffCmd() *cobra.Command {
+	var context int
+
+	cmd := &cobra.Command{
+		Use:   "diff [flags] <run> <run>",
+		Short: "Show a line diff of the output of two runs",
+		Long: "Show a unified diff of the stdout and stderr of two saved runs, with timestamps masked.\n\n" +
+			"A run is an artifact directory such as artifacts/run-0003, or a json or ndjson\n" +
+			"results file and run ID such as results.json#3.\n\n" +
+			"As with diff(1), the exit status is 0 when the outputs are the same, 1 when they\n" +
+			"differ and 2 on trouble.",
+		Args:          cobra.ExactArgs(2),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if context < 0 {
+				return errors.New("context cannot be negative")
+			}
+			differ, err := app.DiffRuns(cmd.OutOrStdout(), args[0], args[1], context)
+			if err != nil {
+				return err
+			}
+			if differ {
+				return errOutputsDiffer
+			}
+			fmt.Fprintln(cmd.ErrOrStderr(), "No differences in output (timestamps ignored)")
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVarP(&context, "context", "U", domain.DiffContext, "Unchanged lines shown around each change")
+	return cmd
+}
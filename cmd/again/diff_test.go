@@ -0,0 +1,53 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiffCmd(t *testing.T) {
+	dir := t.TempDir()
+	run := func(name, stdout string) string {
+		path := filepath.Join(dir, name)
+		if err := os.Mkdir(path, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(path, "stdout"), []byte(stdout), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	passed := run("run-0001", "2026-01-01T10:00:00Z ok\n")
+	same := run("run-0002", "2026-01-01T10:05:00Z ok\n")
+	failed := run("run-0003", "2026-01-01T10:10:00Z connection refused\n")
+
+	tests := []struct {
+		name   string
+		args   []string
+		status int
+	}{
+		{"same output", []string{passed, same}, 0},
+		{"different output", []string{passed, failed}, 1},
+		{"missing run", []string{passed, filepath.Join(dir, "run-0004")}, 2},
+		{"one run", []string{passed}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newDiffCmd()
+			cmd.SetArgs(tt.args)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+
+			status := 0
+			if executed, err := cmd.ExecuteC(); err != nil {
+				status = exitStatus(executed, err)
+			}
+			if status != tt.status {
+				t.Errorf("exit status %d, want %d", status, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/msaeedsaeedi/again/internal/app"
+	"github.com/msaeedsaeedi/again/internal/domain"
+	"github.com/msaeedsaeedi/again/internal/infra"
+	"github.com/msaeedsaeedi/again/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// historyFilter holds the flags that select recorded sessions.
+type historyFilter struct {
+	command string
+	status  string
+	since   string
+}
+
+func (f *historyFilter) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.command, "command", "", "Only sessions whose command line contains this text")
+	cmd.Flags().StringVar(&f.status, "status", "", "Only sessions that ended this way (passed|failed|cancelled)")
+	cmd.Flags().StringVar(&f.since, "since", "", "Only sessions started within this long (e.g. 12h, 7d)")
+}
+
+func (f *historyFilter) isSet() bool {
+	return f.command != "" || f.status != "" || f.since != ""
+}
+
+func (f *historyFilter) build() (domain.SessionFilter, error) {
+	filter := domain.SessionFilter{Command: f.command, Status: domain.SessionStatus(f.status)}
+
+	switch filter.Status {
+	case "", domain.SessionPassed, domain.SessionFailed, domain.SessionCancelled:
+	default:
+		return filter, fmt.Errorf("invalid status: %s", f.status)
+	}
+
+	if f.since != "" {
+		age, err := parseAge(f.since)
+		if err != nil {
+			return filter, err
+		}
+		filter.Since = time.Now().Add(-age)
+	}
+	return filter, nil
+}
+
+// parseAge accepts durations such as "90m" or "12h", and whole days or weeks such as "7d" or "2w".
+func parseAge(value string) (time.Duration, error) {
+	s := strings.TrimSpace(value)
+	units := []struct {
+		suffix string
+		unit   time.Duration
+	}{
+		{"d", 24 * time.Hour}, {"w", 7 * 24 * time.Hour},
+	}
+	for _, u := range units {
+		if count, ok := strings.CutSuffix(s, u.suffix); ok {
+			n, err := strconv.Atoi(count)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid age: %s", value)
+			}
+			return time.Duration(n) * u.unit, nil
+		}
+	}
+
+	age, err := time.ParseDuration(s)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age: %s", value)
+	}
+	return age, nil
+}
+
+// loadSession reads the session with the given ID, or the most recent one for "last".
+func loadSession(history *infra.HistoryStore, ref string) (domain.Session, error) {
+	if ref == "last" {
+		sessions, err := history.List()
+		if err != nil {
+			return domain.Session{}, err
+		}
+		if len(sessions) == 0 {
+			return domain.Session{}, errors.New("no sessions in history")
+		}
+		return history.Load(sessions[0].ID)
+	}
+
+	id, err := strconv.Atoi(ref)
+	if err != nil {
+		return domain.Session{}, fmt.Errorf("invalid session ID %q, expected a number or \"last\"", ref)
+	}
+	return history.Load(id)
+}
+
+func newHistoryCmd() *cobra.Command {
+	var filter historyFilter
+	var limit int
+
+	list := func(cmd *cobra.Command, args []string) error {
+		history, err := infra.OpenHistory()
+		if err != nil {
+			return err
+		}
+		selected, err := filter.build()
+		if err != nil {
+			return err
+		}
+		if limit < 0 {
+			return errors.New("limit cannot be negative")
+		}
+
+		sessions, err := history.List()
+		if err != nil {
+			return err
+		}
+		if len(sessions) == 0 {
+			fmt.Fprintf(os.Stderr, "No sessions recorded in %s, run again with --history to record them\n", history.Path())
+			return nil
+		}
+		sessions = domain.FilterSessions(sessions, selected)
+		if len(sessions) == 0 {
+			fmt.Fprintln(os.Stderr, "No sessions match the filters")
+			return nil
+		}
+		if limit > 0 && len(sessions) > limit {
+			sessions = sessions[:limit]
+		}
+		ui.WriteSessions(os.Stdout, sessions)
+		return nil
+	}
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "List, show, export and prune recorded sessions",
+		Long: "Sessions run with --history are recorded with their configuration, timing and runs.\n" +
+			fmt.Sprintf("Failed runs keep the last %d KB of their stdout and stderr, as do all runs with\n", domain.HistoryOutputBytes/1024) +
+			"--history-output. The oldest sessions are deleted once --history-keep or --history-max-size\n" +
+			"is exceeded.\n\n" +
+			"Without a subcommand, history lists sessions.",
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE:          list,
+	}
+	filter.addFlags(cmd)
+	cmd.Flags().IntVarP(&limit, "limit", "l", 20, "Maximum number of sessions listed, newest first (0 = all)")
+
+	listCmd := &cobra.Command{
+		Use:           "list",
+		Aliases:       []string{"ls"},
+		Short:         "List recorded sessions, newest first",
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE:          list,
+	}
+	filter.addFlags(listCmd)
+	listCmd.Flags().IntVarP(&limit, "limit", "l", 20, "Maximum number of sessions listed, newest first (0 = all)")
+
+	cmd.AddCommand(listCmd, newHistoryShowCmd(), newHistoryExportCmd(), newHistoryPruneCmd())
+	return cmd
+}
+
+func newHistoryShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:           "show <id|last>",
+		Short:         "Describe a recorded session and its runs",
+		Args:          cobra.ExactArgs(1),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			history, err := infra.OpenHistory()
+			if err != nil {
+				return err
+			}
+			session, err := loadSession(history, args[0])
+			if err != nil {
+				return err
+			}
+			ui.WriteSession(os.Stdout, session)
+			return nil
+		},
+	}
+}
+
+func newHistoryExportCmd() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:           "export [flags] <id|last>",
+		Short:         "Output a recorded session in any output format",
+		Args:          cobra.ExactArgs(1),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			history, err := infra.OpenHistory()
+			if err != nil {
+				return err
+			}
+			session, err := loadSession(history, args[0])
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return app.NewOrchestrator().Replay(ctx, session, domain.OutputFormat(format))
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "json", "Output format (tui|json|ndjson|junit|raw)")
+	return cmd
+}
+
+func newHistoryPruneCmd() *cobra.Command {
+	var filter historyFilter
+	var keep int
+	var olderThan string
+	var all, dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "prune [flags]",
+		Short: "Delete recorded sessions",
+		Long: "Delete the sessions selected by --keep, --older-than and the filters. A session is deleted\n" +
+			"when it matches the filters and either rule applies to it; with filters only, every\n" +
+			"matching session is deleted.",
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if keep < 0 {
+				return errors.New("keep cannot be negative")
+			}
+			if keep == 0 && olderThan == "" && !filter.isSet() && !all {
+				return errors.New("nothing to prune, use --keep, --older-than, a filter or --all")
+			}
+
+			selected, err := filter.build()
+			if err != nil {
+				return err
+			}
+			var cutoff time.Time
+			if olderThan != "" {
+				age, err := parseAge(olderThan)
+				if err != nil {
+					return err
+				}
+				cutoff = time.Now().Add(-age)
+			}
+
+			history, err := infra.OpenHistory()
+			if err != nil {
+				return err
+			}
+			sessions, err := history.List()
+			if err != nil {
+				return err
+			}
+
+			pruned := domain.FilterSessions(sessions, selected)
+			if keep > 0 || !cutoff.IsZero() {
+				pruned = domain.SessionsToPrune(pruned, keep, cutoff)
+			}
+
+			verb := "Deleted"
+			if dryRun {
+				verb = "Would delete"
+			}
+			for _, session := range pruned {
+				if !dryRun {
+					if err := history.Delete(session.ID); err != nil {
+						return err
+					}
+				}
+				fmt.Printf("%s session %d (%s)\n", verb, session.ID, session.StartedAt.Local().Format(time.DateTime))
+			}
+			fmt.Fprintf(os.Stderr, "%s %d of %d sessions\n", verb, len(pruned), len(sessions))
+			return nil
+		},
+	}
+
+	filter.addFlags(cmd)
+	cmd.Flags().IntVar(&keep, "keep", 0, "Delete all but the N most recent sessions")
+	cmd.Flags().StringVar(&olderThan, "older-than", "", "Delete sessions started longer ago than this (e.g. 30d)")
+	cmd.Flags().BoolVar(&all, "all", false, "Delete every session")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "List the sessions that would be deleted without deleting them")
+	return cmd
+}
@@ -2,10 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/msaeedsaeedi/again/internal/app"
 	"github.com/msaeedsaeedi/again/internal/domain"
@@ -15,9 +19,54 @@ import (
 const version = "0.1.0-beta"
 
 type options struct {
-	times     int
-	format    string
-	verbosity string
+	times          int
+	parallel       int
+	format         string
+	verbosity      string
+	failFast       bool
+	maxFailures    int
+	maxFailureRate string
+	untilFail      bool
+	untilSuccess   bool
+	untilStreak    int
+	max            int
+	timeBudget     time.Duration
+	killAtDeadline bool
+	timeout        time.Duration
+	killGrace      time.Duration
+	interval       time.Duration
+	jitter         time.Duration
+	rate           string
+	successCodes   []int
+	expectStdout   string
+	rejectOutput   string
+	maxOutput      string
+	retention      string
+	spoolDir       string
+	artifacts      string
+	failedOnly     bool
+	warmup         int
+	includeWarmup  bool
+	setup          string
+	teardown       string
+	beforeEach     string
+	afterEach      string
+	parser         string
+	parseFile      string
+	configPath     string
+	profile        string
+	noSplit        bool
+	interleave     bool
+	templates      bool
+	saveBaseline   string
+	baseline       string
+	threshold      string
+	history        bool
+	historyOutput  bool
+	historyKeep    int
+	historyMaxSize string
+	timesSet       bool
+	sources        domain.ConfigSources // Options set by configuration files
 }
 
 func parseCommand(args []string) []string {
@@ -29,20 +78,205 @@ func parseCommand(args []string) []string {
 	return args
 }
 
-func buildRunConfig(command []string, opts *options) *domain.RunConfig {
+// commandSeparator splits the arguments of several commands to compare.
+const commandSeparator = ":::"
+
+// splitCommands splits a command line such as "./old.sh ::: ./new.sh" into one command per segment.
+func splitCommands(args []string) [][]string {
+	commands := [][]string{{}}
+	for _, arg := range args {
+		if arg == commandSeparator {
+			commands = append(commands, []string{})
+			continue
+		}
+		last := len(commands) - 1
+		commands[last] = append(commands[last], arg)
+	}
+	return commands
+}
+
+// parsePercent accepts values such as "10", "10%" or "2.5%".
+func parsePercent(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	pct, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "%"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid percentage: %s", value)
+	}
+	return pct, nil
+}
+
+// applyOpenEnded switches cfg to an open-ended session where --max replaces --times as the cap.
+func applyOpenEnded(cfg *domain.RunConfig, opts *options) error {
+	switch {
+	case opts.untilFail:
+		cfg.Until = domain.UntilFail
+	case opts.untilSuccess:
+		cfg.Until = domain.UntilSuccess
+	case opts.untilStreak > 0:
+		cfg.Until = domain.UntilStreak
+		cfg.Streak = opts.untilStreak
+	}
+
+	if !cfg.OpenEnded() {
+		if opts.max != 0 {
+			return opts.sources.Wrap("max", errors.New("--max requires --for, --until-fail, --until-success or --until-streak"))
+		}
+		return nil
+	}
+
+	if opts.timesSet {
+		return errors.New("--times cannot be combined with --for or until modes, use --max to cap the number of runs")
+	}
+	cfg.Times = opts.max
+	return nil
+}
+
+// parseRate accepts "N", "N/s", "N/m" or "N/h" and returns starts per second.
+func parseRate(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	count, unit, _ := strings.Cut(strings.TrimSpace(value), "/")
+	n, err := strconv.ParseFloat(count, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rate: %s", value)
+	}
+
+	switch unit {
+	case "", "s":
+		return n, nil
+	case "m":
+		return n / 60, nil
+	case "h":
+		return n / 3600, nil
+	default:
+		return 0, fmt.Errorf("invalid rate unit %q, expected s, m or h", unit)
+	}
+}
+
+// parseSize accepts byte counts such as "512", "64KB", "10MB" or "1GiB".
+func parseSize(value string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	multipliers := []struct {
+		suffix string
+		factor int64
+	}{
+		{"GIB", 1 << 30}, {"MIB", 1 << 20}, {"KIB", 1 << 10},
+		{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+		{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1},
+	}
+
+	factor := int64(1)
+	for _, m := range multipliers {
+		if strings.HasSuffix(s, m.suffix) {
+			s = strings.TrimSuffix(s, m.suffix)
+			factor = m.factor
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size: %s", value)
+	}
+	return int64(n * float64(factor)), nil
+}
+
+func buildRunConfig(commands [][]string, opts *options) (*domain.RunConfig, error) {
+	maxFailureRate, err := parsePercent(opts.maxFailureRate)
+	if err != nil {
+		return nil, opts.sources.Wrap("max-failure-rate", err)
+	}
+
+	rate, err := parseRate(opts.rate)
+	if err != nil {
+		return nil, opts.sources.Wrap("rate", err)
+	}
+
+	maxOutput, err := parseSize(opts.maxOutput)
+	if err != nil {
+		return nil, opts.sources.Wrap("max-output", err)
+	}
+
+	threshold, err := parsePercent(opts.threshold)
+	if err != nil {
+		return nil, opts.sources.Wrap("threshold", err)
+	}
+
+	historyMaxSize, err := parseSize(opts.historyMaxSize)
+	if err != nil {
+		return nil, opts.sources.Wrap("history-max-size", err)
+	}
+
 	cfg := &domain.RunConfig{
-		Command:   command,
-		Times:     opts.times,
-		Verbosity: domain.VerbosityLevel(opts.verbosity),
-		Format:    domain.OutputFormat(opts.format),
+		Command:             commands[0],
+		Interleave:          opts.interleave,
+		Templates:           opts.templates,
+		Times:               opts.times,
+		Parallel:            opts.parallel,
+		Verbosity:           domain.VerbosityLevel(opts.verbosity),
+		Format:              domain.OutputFormat(opts.format),
+		Timeout:             opts.timeout,
+		KillGrace:           opts.killGrace,
+		FailFast:            opts.failFast,
+		MaxFailures:         opts.maxFailures,
+		MaxFailureRate:      maxFailureRate,
+		TimeBudget:          opts.timeBudget,
+		KillAtDeadline:      opts.killAtDeadline,
+		Interval:            opts.interval,
+		Jitter:              opts.jitter,
+		Rate:                rate,
+		SuccessCodes:        opts.successCodes,
+		ExpectStdout:        opts.expectStdout,
+		RejectOutput:        opts.rejectOutput,
+		MaxOutput:           maxOutput,
+		OutputRetention:     domain.RetentionMode(opts.retention),
+		SpoolDir:            opts.spoolDir,
+		ArtifactsDir:        opts.artifacts,
+		ArtifactsFailedOnly: opts.failedOnly,
+		Warmup:              opts.warmup,
+		IncludeWarmup:       opts.includeWarmup,
+		Setup:               opts.setup,
+		Teardown:            opts.teardown,
+		BeforeEach:          opts.beforeEach,
+		AfterEach:           opts.afterEach,
+		Parser:              domain.ParserKind(opts.parser),
+		ParseFile:           opts.parseFile,
+		SaveBaseline:        opts.saveBaseline,
+		Baseline:            opts.baseline,
+		Threshold:           threshold,
+		History:             opts.history,
+		HistoryOutput:       opts.historyOutput,
+		HistoryKeep:         opts.historyKeep,
+		HistoryMaxSize:      historyMaxSize,
+		Sources:             opts.sources,
 	}
 
-	return cfg
+	if len(commands) > 1 {
+		cfg.Commands = commands
+	}
+
+	if err := applyOpenEnded(cfg, opts); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
 }
 
 func run(args []string, opts *options) error {
-	command := parseCommand(args)
-	cfg := buildRunConfig(command, opts)
+	// --no-split keeps ::: for commands that take it as an argument, such as GNU parallel
+	commands := [][]string{parseCommand(args)}
+	if !opts.noSplit {
+		commands = splitCommands(commands[0])
+	}
+
+	cfg, err := buildRunConfig(commands, opts)
+	if err != nil {
+		return err
+	}
 
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
@@ -61,19 +295,77 @@ func run(args []string, opts *options) error {
 
 func newRootCmd(opts *options) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:                "again [flags] -- <command>",
-		Short:              "Run commands multiple times",
-		Long:               "again - A powerful CLI tool to execute commands multiple times",
+		Use:   "again [flags] -- <command> [::: <command>...]",
+		Short: "Run commands multiple times",
+		Long: "again - A powerful CLI tool to execute commands multiple times\n\n" +
+			"Diffing saved runs and the run history are under again tools.",
 		SilenceErrors:      true,
 		DisableFlagParsing: false,
+		// Anything that is not a subcommand is the command to run
+		Args: cobra.ArbitraryArgs,
+		// Nor is completion, which cobra would add as a subcommand otherwise
+		CompletionOptions: cobra.CompletionOptions{DisableDefaultCmd: true},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			// Only an explicit --times conflicts with open-ended modes; a configured one is a default
+			opts.timesSet = cmd.Flags().Changed("times")
+
+			sources, err := loadConfig(cmd, opts)
+			if err != nil {
+				return err
+			}
+			opts.sources = sources
+
 			return run(args, opts)
 		},
 		SilenceUsage: true,
 	}
 
 	cmd.Flags().IntVarP(&opts.times, "times", "n", 1, "Number of times to run a command")
-	cmd.Flags().StringVarP(&opts.format, "format", "f", "tui", "Output format (tui|json|raw)")
+	cmd.Flags().IntVarP(&opts.parallel, "parallel", "p", 1, "Number of iterations to run concurrently")
+	cmd.Flags().DurationVar(&opts.timeout, "timeout", 0, "Per-run timeout (e.g. 30s, 0 disables)")
+	cmd.Flags().DurationVar(&opts.killGrace, "kill-grace", 0, "Time between SIGTERM and SIGKILL when stopping a run (0 sends SIGKILL immediately, with no SIGTERM grace)")
+	cmd.Flags().DurationVar(&opts.interval, "interval", 0, "Minimum time between run starts (e.g. 500ms)")
+	cmd.Flags().DurationVar(&opts.jitter, "jitter", 0, "Random extra delay added to each interval")
+	cmd.Flags().StringVar(&opts.rate, "rate", "", "Maximum run starts per unit of time (e.g. 5/s, 30/m)")
+	cmd.Flags().BoolVar(&opts.templates, "templates", false, "Expand templates such as {{.ID}} in command and hook arguments")
+	cmd.Flags().IntSliceVar(&opts.successCodes, "success-codes", nil, "Exit codes counted as success (default 0)")
+	cmd.Flags().StringVar(&opts.expectStdout, "expect-stdout", "", "Regexp stdout must match for a run to succeed")
+	cmd.Flags().StringVar(&opts.rejectOutput, "reject-output", "", "Regexp that fails a run when stdout or stderr matches")
+	cmd.Flags().StringVar(&opts.maxOutput, "max-output", "10MB", "Output retained per stream and run (e.g. 64KB, 10MB)")
+	cmd.Flags().StringVar(&opts.retention, "output-retention", "head", "Part of oversized output to keep (head|head-tail)")
+	cmd.Flags().StringVar(&opts.spoolDir, "spool-dir", "", "Write complete output of every run to files in this directory")
+	cmd.Flags().StringVar(&opts.artifacts, "artifacts", "", "Save stdout, stderr and metadata of every run under DIR/run-NNNN")
+	cmd.Flags().BoolVar(&opts.failedOnly, "artifacts-failed-only", false, "Only keep artifacts of failed runs")
+	cmd.Flags().IntVar(&opts.warmup, "warmup", 0, "Iterations to run before the measured ones, excluded from results")
+	cmd.Flags().BoolVar(&opts.includeWarmup, "include-warmup", false, "Report warmup iterations separately in json, ndjson and junit output")
+	cmd.Flags().StringVar(&opts.setup, "setup", "", "Shell command run once before the first run")
+	cmd.Flags().StringVar(&opts.teardown, "teardown", "", "Shell command run once after the last run")
+	cmd.Flags().StringVar(&opts.beforeEach, "before-each", "", "Shell command run before every run, excluded from its duration")
+	cmd.Flags().StringVar(&opts.afterEach, "after-each", "", "Shell command run after every run, excluded from its duration")
+	cmd.Flags().StringVar(&opts.parser, "parse", "", "Parse per-test results from run output (gotest|tap|junit)")
+	cmd.Flags().StringVar(&opts.parseFile, "parse-file", "", "Report file each run writes, parsed instead of stdout (e.g. {{.ScratchDir}}/junit.xml)")
+	cmd.Flags().BoolVar(&opts.noSplit, "no-split", false, "Pass ::: to the command as an argument instead of comparing commands")
+	cmd.Flags().BoolVar(&opts.interleave, "interleave", false, "Alternate between commands compared with ::: to reduce drift")
+	cmd.Flags().StringVar(&opts.saveBaseline, "save-baseline", "", "Save the measured durations and success rate to a baseline file")
+	cmd.Flags().StringVar(&opts.baseline, "compare", "", "Fail when runs are significantly slower than this baseline file")
+	cmd.Flags().StringVar(&opts.threshold, "threshold", "5%", "Slowdown from the --compare baseline tolerated before failing")
+	cmd.Flags().BoolVar(&opts.history, "history", false, "Record the session, its command line and failed runs' output in the run history")
+	cmd.Flags().BoolVar(&opts.historyOutput, "history-output", false, "Record the output of every run in the history, not only of failed runs")
+	cmd.Flags().IntVar(&opts.historyKeep, "history-keep", domain.DefaultHistoryKeep, "Sessions kept in the history, oldest deleted first (0 = unlimited)")
+	cmd.Flags().StringVar(&opts.historyMaxSize, "history-max-size", "100MB", "Disk space the history may take up, oldest sessions deleted first (0 = unlimited)")
+	cmd.Flags().BoolVar(&opts.failFast, "fail-fast", false, "Stop after the first failed run")
+	cmd.Flags().IntVar(&opts.maxFailures, "max-failures", 0, "Stop after N failed runs (0 disables)")
+	cmd.Flags().StringVar(&opts.maxFailureRate, "max-failure-rate", "", "Stop once more than X% of runs have failed (e.g. 10%)")
+	cmd.Flags().BoolVar(&opts.untilFail, "until-fail", false, "Keep running until a run fails")
+	cmd.Flags().BoolVar(&opts.untilSuccess, "until-success", false, "Keep running until a run succeeds")
+	cmd.Flags().IntVar(&opts.untilStreak, "until-streak", 0, "Keep running until N consecutive runs succeed")
+	cmd.Flags().IntVar(&opts.max, "max", 0, "Maximum number of runs with --for or until modes (0 = unlimited)")
+	cmd.Flags().DurationVar(&opts.timeBudget, "for", 0, "Keep starting runs until this much time has passed (e.g. 10m)")
+	cmd.Flags().BoolVar(&opts.killAtDeadline, "kill-at-deadline", false, "Kill in-flight runs when the --for budget expires")
+	cmd.MarkFlagsMutuallyExclusive("until-fail", "until-success", "until-streak")
+	cmd.Flags().StringVar(&opts.configPath, "config", "", "Configuration file to use instead of the project's .again.yaml")
+	cmd.Flags().StringVar(&opts.profile, "profile", "", "Apply a named profile from the configuration files")
+	cmd.Flags().StringVarP(&opts.format, "format", "f", "tui", "Output format (tui|json|ndjson|junit|raw)")
 	cmd.Flags().StringVarP(&opts.verbosity, "verbosity", "v", "normal", "Verbosity level (silent|normal|verbose)")
 	cmd.Version = version
 
@@ -83,10 +375,25 @@ func newRootCmd(opts *options) *cobra.Command {
 func main() {
 	opts := &options{}
 	rootCmd := newRootCmd(opts)
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, "Error:", err)
-		fmt.Fprintln(os.Stderr)
-		rootCmd.Usage()
-		os.Exit(1)
+	rootCmd.AddCommand(newToolsCmd())
+	if cmd, err := rootCmd.ExecuteC(); err != nil {
+		// Differing outputs are reported by the diff itself
+		if !errors.Is(err, errOutputsDiffer) {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			if !app.IsRunOutcome(err) {
+				fmt.Fprintln(os.Stderr)
+				cmd.Usage()
+			}
+		}
+		os.Exit(exitStatus(cmd, err))
+	}
+}
+
+// exitStatus returns the status to exit with when cmd failed with err. again tools diff
+// follows diff(1): 1 means that the outputs differ, and trouble is reported with 2.
+func exitStatus(cmd *cobra.Command, err error) int {
+	if cmd.Name() == "diff" && !errors.Is(err, errOutputsDiffer) {
+		return 2
 	}
+	return 1
 }
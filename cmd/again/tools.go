@@ -0,0 +1,17 @@
+package main
+
+import "github.com/spf13/cobra"
+
+// newToolsCmd groups again's own subcommands under a single name, so that any
+// other first argument is still the command to run, as in again diff a b or again history.
+func newToolsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "tools",
+		Short:         "Diff saved runs and browse the run history",
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+	cmd.AddCommand(newDiffCmd(), newHistoryCmd())
+	return cmd
+}
@@ -0,0 +1,231 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/msaeedsaeedi/again/internal/domain"
+)
+
+var (
+	// ErrFailureThreshold is returned when execution stops early because too many runs failed.
+	ErrFailureThreshold = errors.New("failure threshold reached")
+	// ErrFailureFound is returned when an until-fail session reproduced a failure.
+	ErrFailureFound = errors.New("failure reproduced")
+	// ErrUntilNotMet is returned when the run cap was reached before the until condition held.
+	ErrUntilNotMet = errors.New("until condition not met")
+	// ErrHookFailed is returned when the setup or teardown hook failed.
+	ErrHookFailed = errors.New("hook failed")
+	// ErrRegression is returned when runs were significantly slower than the baseline.
+	ErrRegression = errors.New("performance regression")
+)
+
+// minRateRuns is how many runs an open-ended session completes before --max-failure-rate
+// applies, as the rate of a handful of runs says little and the first failure would be 100%.
+const minRateRuns = 10
+
+// runController hands out iteration IDs to executors and decides when to stop early.
+// It is safe for concurrent use by multiple workers.
+type runController struct {
+	cfg       *domain.RunConfig
+	cancel    context.CancelFunc // Aborts in-flight runs
+	wake      context.CancelFunc // Wakes workers waiting for their next start
+	mu        sync.Mutex
+	next      int
+	completed int
+	failures  int
+	streak    int
+	halted    bool
+	goalMet   bool
+	stopErr   error
+	deadline  *time.Timer
+}
+
+func newRunController(cfg *domain.RunConfig, cancel, wake context.CancelFunc) *runController {
+	return &runController{
+		cfg:    cfg,
+		cancel: cancel,
+		wake:   wake,
+		next:   1,
+	}
+}
+
+// Start begins the time budget. It is called once the measured runs are about to begin,
+// so setup and warmup do not eat into it.
+func (c *runController) Start() {
+	if c.cfg.TimeBudget > 0 {
+		c.deadline = time.AfterFunc(c.cfg.TimeBudget, c.expire)
+	}
+}
+
+// expire stops new runs from starting once the time budget is spent. Workers waiting
+// for their next start are woken, while in-flight runs finish unless killed at the deadline.
+func (c *runController) expire() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.halted = true
+	if c.wake != nil {
+		c.wake()
+	}
+	if c.cfg.KillAtDeadline && c.cancel != nil {
+		c.cancel()
+	}
+}
+
+// Close releases the deadline timer.
+func (c *runController) Close() {
+	if c.deadline != nil {
+		c.deadline.Stop()
+	}
+}
+
+// HasNext reports whether another run may start, without claiming it.
+func (c *runController) HasNext() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hasNext()
+}
+
+func (c *runController) hasNext() bool {
+	total := c.cfg.TotalRuns()
+	return !c.halted && (total == 0 || c.next <= total)
+}
+
+// Next claims the next iteration ID, or reports false when no more runs should start.
+func (c *runController) Next() (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.hasNext() {
+		return 0, false
+	}
+
+	id := c.next
+	c.next++
+	return id, true
+}
+
+// Record accounts a finished run and halts execution once a failure threshold
+// is crossed or the until condition is met.
+func (c *runController) Record(result domain.RunResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if result.Status == domain.StatusSkipped {
+		return
+	}
+
+	c.completed++
+	if result.Success {
+		c.streak++
+	} else {
+		c.failures++
+		c.streak = 0
+	}
+
+	if c.halted {
+		return
+	}
+
+	if c.untilMet(result) {
+		c.goalMet = true
+		if c.cfg.Until == domain.UntilFail {
+			c.stopErr = fmt.Errorf("%w: run %d failed", ErrFailureFound, result.ID)
+		}
+		c.halt()
+		return
+	}
+
+	if result.Success {
+		return
+	}
+
+	if reason := c.thresholdReason(); reason != "" {
+		c.stopErr = fmt.Errorf("%w: %s", ErrFailureThreshold, reason)
+		c.halt()
+	}
+}
+
+func (c *runController) untilMet(result domain.RunResult) bool {
+	switch c.cfg.Until {
+	case domain.UntilFail:
+		return !result.Success
+	case domain.UntilSuccess:
+		return result.Success
+	case domain.UntilStreak:
+		return c.streak >= c.cfg.Streak
+	default:
+		return false
+	}
+}
+
+func (c *runController) thresholdReason() string {
+	total := c.cfg.TotalRuns()
+	rateKnown := true
+	if c.cfg.OpenEnded() {
+		total = c.completed
+		rateKnown = c.completed >= minRateRuns
+	}
+
+	switch {
+	case c.cfg.FailFast:
+		return "run failed with fail-fast enabled"
+	case c.cfg.MaxFailures > 0 && c.failures >= c.cfg.MaxFailures:
+		return fmt.Sprintf("%d failures (max %d)", c.failures, c.cfg.MaxFailures)
+	case c.cfg.MaxFailureRate > 0 && rateKnown && float64(c.failures)*100 > c.cfg.MaxFailureRate*float64(total):
+		return fmt.Sprintf("%d of %d runs failed (max %g%%)", c.failures, total, c.cfg.MaxFailureRate)
+	}
+	return ""
+}
+
+func (c *runController) halt() {
+	c.halted = true
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
+
+// Stopped reports whether execution was halted before running out of iterations.
+func (c *runController) Stopped() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.halted
+}
+
+// Remaining returns the planned IDs that were never claimed, to be reported as skipped.
+// Open-ended sessions have no planned runs left over.
+func (c *runController) Remaining() []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.halted || c.cfg.OpenEnded() {
+		return nil
+	}
+
+	total := c.cfg.TotalRuns()
+	var ids []int
+	for id := c.next; id <= total; id++ {
+		ids = append(ids, id)
+	}
+	c.next = total + 1
+	return ids
+}
+
+func (c *runController) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stopErr != nil {
+		return c.stopErr
+	}
+
+	// Running out of the cap is only a failure when we were waiting for success
+	if !c.goalMet && (c.cfg.Until == domain.UntilSuccess || c.cfg.Until == domain.UntilStreak) {
+		return fmt.Errorf("%w after %d runs", ErrUntilNotMet, c.completed)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package app
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/msaeedsaeedi/again/internal/domain"
+	"github.com/msaeedsaeedi/again/internal/infra"
+)
+
+// DiffRuns writes unified diffs of the stdout and stderr of two saved runs to w,
+// with timestamps masked. It reports whether any output differs.
+func DiffRuns(w io.Writer, oldRef, newRef string, context int) (bool, error) {
+	oldRun, err := infra.LoadSavedRun(oldRef)
+	if err != nil {
+		return false, err
+	}
+	newRun, err := infra.LoadSavedRun(newRef)
+	if err != nil {
+		return false, err
+	}
+
+	streams := []struct {
+		name     string
+		old, new []byte
+	}{
+		{"stdout", oldRun.Stdout, newRun.Stdout},
+		{"stderr", oldRun.Stderr, newRun.Stderr},
+	}
+
+	differ := false
+	for _, s := range streams {
+		hunks := domain.UnifiedDiff(domain.DiffableLines(s.old), domain.DiffableLines(s.new), context)
+		if len(hunks) == 0 {
+			continue
+		}
+		differ = true
+		diff := domain.FormatUnifiedDiff(oldRun.Label+" "+s.name, newRun.Label+" "+s.name, hunks)
+		if _, err := io.WriteString(w, diff); err != nil {
+			return differ, fmt.Errorf("write diff: %w", err)
+		}
+	}
+	return differ, nil
+}
@@ -2,7 +2,12 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"os"
+	"sync"
+	"time"
 
 	"github.com/msaeedsaeedi/again/internal/domain"
 	"github.com/msaeedsaeedi/again/internal/infra"
@@ -12,7 +17,36 @@ type ResultHandler interface {
 	OnStart(runID int)
 	OnComplete(result domain.RunResult)
 	OnFinish()
-	GetOutputWriters() (stdout, stderr io.Writer)
+	GetOutputWriters(runID int) (stdout, stderr io.Writer)
+}
+
+// HookObserver is implemented by handlers that display hooks as their own phases.
+// runID is 0 for the session-wide setup and teardown hooks.
+type HookObserver interface {
+	OnHookStart(runID int, phase domain.HookPhase)
+	OnHookComplete(runID int, result domain.HookResult)
+}
+
+// WarmupObserver is implemented by handlers that display warmup iterations.
+// Warmups are numbered from 1 independently of measured runs; handlers that do
+// not implement this interface never see them.
+type WarmupObserver interface {
+	OnWarmupStart(n int)
+	OnWarmupComplete(result domain.RunResult)
+	GetWarmupWriters(n int) (stdout, stderr io.Writer)
+}
+
+// BaselineObserver is implemented by handlers that report the check against a
+// saved baseline. It is called once, right before OnFinish.
+type BaselineObserver interface {
+	OnBaselineCheck(check domain.BaselineCheck)
+}
+
+// WarningObserver is implemented by handlers that report problems which do not change
+// the outcome of the session, such as failing to record it in the history. Warnings
+// for other handlers are printed to stderr.
+type WarningObserver interface {
+	OnWarning(err error)
 }
 
 type Executor interface {
@@ -30,29 +64,618 @@ func NewSequentialExecutor(runner *infra.CommandRunner) *SequentialExecutor {
 }
 
 func (e *SequentialExecutor) Execute(ctx context.Context, cfg *domain.RunConfig, handler ResultHandler) error {
-	results := make([]domain.RunResult, 0, cfg.Times)
+	// Cancelling runCtx aborts the current run when the time budget expires,
+	// cancelling waitCtx only cuts short the wait for the next start
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	waitCtx, wake := context.WithCancel(runCtx)
+	defer wake()
+
+	x, err := newExecution(cfg, e.runner, handler, cancel, wake)
+	if err != nil {
+		return err
+	}
+	defer x.Close()
+
+	if err := x.setup(runCtx); err != nil {
+		handler.OnFinish()
+		return err
+	}
+
+	x.warmup(runCtx, 1)
+	x.ctrl.Start()
+
+	for x.ctrl.HasNext() {
+		// Halting or cancellation interrupts the wait; both are handled below
+		_ = x.sched.Wait(waitCtx)
 
-	for i := 1; i <= cfg.Times; i++ {
 		select {
 		case <-ctx.Done():
-			handler.OnFinish()
+			x.finish(ctx)
 			return ctx.Err()
 		default:
 		}
 
-		handler.OnStart(i)
+		id, ok := x.ctrl.Next()
+		if !ok {
+			break
+		}
+
+		x.runIteration(runCtx, id, 1)
+	}
+
+	x.finish(ctx)
+	return x.Err()
+}
+
+// ParallelExecutor runs iterations on a bounded pool of workers.
+type ParallelExecutor struct {
+	runner  *infra.CommandRunner
+	workers int
+}
+
+func NewParallelExecutor(runner *infra.CommandRunner, workers int) *ParallelExecutor {
+	return &ParallelExecutor{
+		runner:  runner,
+		workers: workers,
+	}
+}
+
+func (e *ParallelExecutor) Execute(ctx context.Context, cfg *domain.RunConfig, handler ResultHandler) error {
+	handler = newSyncHandler(handler)
+
+	// Cancelling runCtx aborts in-flight runs when execution is halted,
+	// cancelling waitCtx wakes workers waiting for their next start
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	waitCtx, wake := context.WithCancel(runCtx)
+	defer wake()
+
+	x, err := newExecution(cfg, e.runner, handler, cancel, wake)
+	if err != nil {
+		return err
+	}
+	defer x.Close()
+
+	if err := x.setup(runCtx); err != nil {
+		handler.OnFinish()
+		return err
+	}
+
+	x.warmup(runCtx, e.workers)
+	x.ctrl.Start()
+
+	workers := e.workers
+	if total := cfg.TotalRuns(); total > 0 {
+		workers = min(workers, total)
+	}
+
+	var wg sync.WaitGroup
+	for w := 1; w <= workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for runCtx.Err() == nil && x.ctrl.HasNext() {
+				if err := x.sched.Wait(waitCtx); err != nil {
+					return
+				}
+				id, ok := x.ctrl.Next()
+				if !ok {
+					return
+				}
+				x.runIteration(runCtx, id, w)
+			}
+		}()
+	}
+	wg.Wait()
+
+	x.finish(ctx)
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return x.Err()
+}
+
+// execution holds the state shared by every iteration of one Execute call.
+type execution struct {
+	cfg       *domain.RunConfig
+	runner    *infra.CommandRunner
+	handler   ResultHandler
+	ctrl      *runController
+	sched     *startScheduler
+	criteria  *domain.SuccessCriteria
+	parser    TestParser // Nil unless per-test results are parsed
+	artifacts *infra.ArtifactStore
+	hooks     HookObserver   // Nil when the handler does not display hooks
+	warmups   WarmupObserver // Nil when the handler does not display warmups
+	baseline  *domain.Baseline
+	stats     *domain.StatsCollector // Measured runs, kept for baselines
+	started   time.Time
+	results   []domain.RunResult // Measured runs with trimmed output, kept for the history
+	mu        sync.Mutex
+	errs      []error // Unreadable test reports, teardown failures and baseline problems, reported once execution ends
+}
+
+func newExecution(cfg *domain.RunConfig, runner *infra.CommandRunner, handler ResultHandler, cancel, wake context.CancelFunc) (*execution, error) {
+	criteria, err := domain.NewSuccessCriteria(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	parser, err := NewTestParser(cfg.Parser)
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts, err := infra.NewArtifactStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	x := &execution{
+		cfg:       cfg,
+		runner:    runner,
+		handler:   handler,
+		ctrl:      newRunController(cfg, cancel, wake),
+		sched:     newStartScheduler(cfg, handler),
+		criteria:  criteria,
+		parser:    parser,
+		artifacts: artifacts,
+		stats:     domain.NewStatsCollector(),
+		started:   time.Now(),
+	}
+	// Load the baseline up front so a bad path fails before anything runs
+	if cfg.Baseline != "" {
+		baseline, err := infra.LoadBaseline(cfg.Baseline)
+		if err != nil {
+			return nil, err
+		}
+		x.baseline = &baseline
+	}
+	if observer, ok := handler.(HookObserver); ok {
+		x.hooks = observer
+	}
+	if observer, ok := handler.(WarmupObserver); ok {
+		x.warmups = observer
+	}
+	return x, nil
+}
+
+func (x *execution) Close() {
+	x.ctrl.Close()
+}
+
+func (x *execution) runIteration(ctx context.Context, id, worker int) {
+	x.handler.OnStart(id)
+
+	result := x.run(ctx, domain.NewRunInfo(x.cfg, id, worker))
+
+	x.ctrl.Record(result)
+	x.mu.Lock()
+	x.stats.Add(result)
+	x.mu.Unlock()
+	x.keepForHistory(result)
+	x.handler.OnComplete(result)
+}
+
+func (x *execution) keepForHistory(result domain.RunResult) {
+	if !x.cfg.History {
+		return
+	}
+	// Output mostly matters for understanding failures, so only theirs is kept by default
+	failed := !result.Success && result.Status != domain.StatusSkipped
+	result = result.ForHistory(x.cfg.HistoryOutput || failed)
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.results = append(x.results, result)
+}
+
+// run executes one iteration in its own scratch directory and persists its artifacts.
+func (x *execution) run(ctx context.Context, info domain.RunInfo) domain.RunResult {
+	cfg := x.commandConfig(info)
+
+	scratch, err := infra.NewScratchDir(info.ID)
+	if err != nil {
+		result := domain.StartFailedResult(info.ID, cfg.Command, err)
+		result.CommandIndex = info.CommandIndex
+		return result
+	}
+	defer os.RemoveAll(scratch)
+	info.ScratchDir = scratch
+
+	stdoutWriter, stderrWriter := x.outputWriters(info)
+
+	var result domain.RunResult
+	before, ok := x.runHook(ctx, domain.HookBeforeEach, info, stdoutWriter, stderrWriter)
+	if ok && !before.Success {
+		result = domain.HookFailedResult(info.ID, cfg.Command, before)
+	} else {
+		result = x.runner.Run(ctx, cfg, info, stdoutWriter, stderrWriter)
+		x.criteria.Apply(&result)
+		x.parseTests(&result, info)
+		if ok {
+			result.Hooks = append(result.Hooks, before)
+		}
+	}
+
+	if after, ok := x.runHook(ctx, domain.HookAfterEach, info, stdoutWriter, stderrWriter); ok {
+		result.Hooks = append(result.Hooks, after)
+	}
+	result.CommandIndex = info.CommandIndex
+
+	// A run aborted because another one halted execution did not really fail
+	if x.ctrl.Stopped() && interrupted(result) {
+		result.Status = domain.StatusSkipped
+	}
+
+	result.Signature = domain.FailureSignature(result)
+
+	if info.Warmup {
+		result.Warmup = true
+		return result
+	}
+
+	// Artifacts only help investigate the run, so failing to save them does not change its outcome
+	dir, err := x.artifacts.Save(result, info)
+	if err != nil {
+		x.warn(err)
+	}
+	result.ArtifactDir = dir
+
+	return result
+}
+
+// interrupted reports whether the run, or the before-each hook it never got past, was
+// stopped by cancellation rather than finishing on its own, even if it failed.
+func interrupted(result domain.RunResult) bool {
+	if result.Failure == domain.FailureHook && len(result.Hooks) > 0 {
+		return result.Hooks[0].Failure == domain.FailureCancelled
+	}
+	return result.Failure == domain.FailureCancelled
+}
+
+// parseTests records the test cases found in the run's report. Reports that
+// cannot be read are reported once execution ends rather than failing the run.
+func (x *execution) parseTests(result *domain.RunResult, info domain.RunInfo) {
+	if x.parser == nil || result.Failure == domain.FailureStartError {
+		return
+	}
+
+	report, err := openReport(x.cfg, info, *result)
+	if err != nil {
+		x.addErr(fmt.Errorf("run %d: parse tests: %w", result.ID, err))
+		return
+	}
+	if report == nil {
+		return
+	}
+	defer report.Close()
+
+	tests, err := x.parser.Parse(report)
+	if err != nil {
+		x.addErr(fmt.Errorf("run %d: parse tests: %w", result.ID, err))
+	}
+	result.Tests = tests
+}
+
+// commandConfig returns the configuration to run info's command with, which
+// differs from the session's only in the command when comparing.
+func (x *execution) commandConfig(info domain.RunInfo) *domain.RunConfig {
+	if info.CommandIndex == 0 {
+		return x.cfg
+	}
+	cfg := *x.cfg
+	cfg.Command = x.cfg.CommandFor(info.CommandIndex)
+	return &cfg
+}
+
+func (x *execution) addErr(err error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.errs = append(x.errs, err)
+}
+
+// Err combines why execution stopped with the problems collected in errs.
+func (x *execution) Err() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if len(x.errs) == 0 {
+		return x.ctrl.Err()
+	}
+	return errors.Join(append([]error{x.ctrl.Err()}, x.errs...)...)
+}
+
+func (x *execution) outputWriters(info domain.RunInfo) (stdout, stderr io.Writer) {
+	if !info.Warmup {
+		return x.handler.GetOutputWriters(info.ID)
+	}
+	if x.warmups != nil {
+		return x.warmups.GetWarmupWriters(info.ID)
+	}
+	return nil, nil
+}
+
+// warmup runs the warmup iterations of every command on up to workers goroutines.
+// Warmups share pacing, hooks and success criteria with measured runs but never
+// count towards results, thresholds or statistics.
+func (x *execution) warmup(ctx context.Context, workers int) {
+	total := x.cfg.TotalWarmups()
+	if total == 0 {
+		return
+	}
+
+	ids := make(chan int, total)
+	for n := 1; n <= total; n++ {
+		ids <- n
+	}
+	close(ids)
+
+	var wg sync.WaitGroup
+	for w := 1; w <= min(workers, total); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range ids {
+				if err := x.sched.Wait(ctx); err != nil {
+					return
+				}
+
+				info := domain.NewWarmupInfo(x.cfg, n, w)
+				if x.warmups != nil {
+					x.warmups.OnWarmupStart(n)
+				}
+				result := x.run(ctx, info)
+				if x.warmups != nil {
+					x.warmups.OnWarmupComplete(result)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
 
-		stdoutWriter, stderrWriter := handler.GetOutputWriters()
-		result := e.runner.Run(ctx, cfg, i, stdoutWriter, stderrWriter)
-		results = append(results, result)
+// runHook runs the hook configured for phase outside of any measured duration.
+// It reports false when no hook is configured.
+func (x *execution) runHook(ctx context.Context, phase domain.HookPhase, info domain.RunInfo, stdout, stderr io.Writer) (domain.HookResult, bool) {
+	command := x.cfg.Hook(phase)
+	if command == nil {
+		return domain.HookResult{}, false
+	}
 
-		handler.OnComplete(result)
+	// Warmup IDs would be mistaken for runs, so their hooks are only reported with the result
+	observer := x.hooks
+	if info.Warmup {
+		observer = nil
+	}
+	if observer != nil {
+		observer.OnHookStart(info.ID, phase)
 	}
 
-	handler.OnFinish()
+	// Hooks share the run's timeouts and environment but never its spool files
+	cfg := *x.cfg
+	cfg.Command = command
+	cfg.SpoolDir = ""
+	hook := domain.NewHookResult(phase, x.runner.Run(ctx, &cfg, info, stdout, stderr))
+
+	if observer != nil {
+		observer.OnHookComplete(info.ID, hook)
+	}
+	return hook, true
+}
+
+// setup runs the setup hook once before the first run.
+func (x *execution) setup(ctx context.Context) error {
+	stdout, stderr := x.handler.GetOutputWriters(0)
+	hook, ok := x.runHook(ctx, domain.HookSetup, domain.NewRunInfo(x.cfg, 0, 0), stdout, stderr)
+	if ok && !hook.Success {
+		return fmt.Errorf("setup %w: %s", ErrHookFailed, hook.Reason)
+	}
 	return nil
 }
 
+// teardown runs the teardown hook once after the last run. It also runs when
+// the session was interrupted, so it must not inherit cancellation.
+func (x *execution) teardown(ctx context.Context) {
+	stdout, stderr := x.handler.GetOutputWriters(0)
+	hook, ok := x.runHook(context.WithoutCancel(ctx), domain.HookTeardown, domain.NewRunInfo(x.cfg, 0, 0), stdout, stderr)
+	if ok && !hook.Success {
+		x.addErr(fmt.Errorf("teardown %w: %s", ErrHookFailed, hook.Reason))
+	}
+}
+
+// finish runs the teardown hook, reports never-started runs as skipped, checks and
+// saves baselines, records the session and signals the handler.
+func (x *execution) finish(ctx context.Context) {
+	x.teardown(ctx)
+	for _, id := range x.ctrl.Remaining() {
+		skipped := domain.SkippedResult(id)
+		skipped.CommandIndex = x.cfg.CommandIndex(id)
+		x.keepForHistory(skipped)
+		x.handler.OnComplete(skipped)
+	}
+	x.checkBaseline()
+	x.saveBaseline()
+	x.recordHistory(ctx)
+	x.handler.OnFinish()
+}
+
+// checkBaseline tests the measured runs against the loaded baseline and fails
+// the session on a significant regression.
+func (x *execution) checkBaseline() {
+	if x.baseline == nil {
+		return
+	}
+
+	x.mu.Lock()
+	check := domain.CheckBaseline(*x.baseline, x.stats, x.cfg.Threshold)
+	x.mu.Unlock()
+
+	if observer, ok := x.handler.(BaselineObserver); ok {
+		observer.OnBaselineCheck(check)
+	}
+	if check.Regressed() {
+		x.addErr(fmt.Errorf("%w: mean %v is %.1f%% slower than the baseline's %v (threshold %g%%, p=%.4f)", ErrRegression,
+			check.Current.Mean.Round(time.Microsecond), check.Change, check.Previous.Mean.Round(time.Microsecond), check.Threshold, check.PValue))
+	}
+}
+
+func (x *execution) saveBaseline() {
+	if x.cfg.SaveBaseline == "" {
+		return
+	}
+
+	x.mu.Lock()
+	baseline := domain.NewBaseline(x.cfg.Command, x.stats)
+	x.mu.Unlock()
+
+	if len(baseline.Durations) == 0 {
+		x.addErr(errors.New("save baseline: no measured runs"))
+		return
+	}
+	if err := infra.SaveBaseline(x.cfg.SaveBaseline, baseline); err != nil {
+		x.addErr(err)
+	}
+}
+
+// recordHistory saves the session to the run history with how it ended so far.
+func (x *execution) recordHistory(ctx context.Context) {
+	if !x.cfg.History {
+		return
+	}
+
+	err := x.Err()
+	x.mu.Lock()
+	session := domain.NewSession(x.cfg, x.started, x.results, err, ctx.Err() != nil)
+	x.mu.Unlock()
+
+	history, err := infra.OpenHistory()
+	if err == nil {
+		_, err = history.Save(session)
+	}
+	if err == nil {
+		_, err = history.Trim(x.cfg.HistoryKeep, x.cfg.HistoryMaxSize)
+	}
+	if err != nil {
+		x.warn(err)
+	}
+}
+
+// warn reports a problem that must not change the outcome of the session.
+func (x *execution) warn(err error) {
+	if observer, ok := x.handler.(WarningObserver); ok {
+		observer.OnWarning(err)
+		return
+	}
+	fmt.Fprintln(os.Stderr, "Warning:", err)
+}
+
+// syncHandler serializes callbacks so formatters never see them concurrently.
+type syncHandler struct {
+	mu      sync.Mutex
+	handler ResultHandler
+}
+
+func newSyncHandler(handler ResultHandler) *syncHandler {
+	return &syncHandler{handler: handler}
+}
+
+func (h *syncHandler) OnStart(runID int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.handler.OnStart(runID)
+}
+
+func (h *syncHandler) OnComplete(result domain.RunResult) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.handler.OnComplete(result)
+}
+
+func (h *syncHandler) OnFinish() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.handler.OnFinish()
+}
+
+func (h *syncHandler) OnScheduled(at time.Time) {
+	if observer, ok := h.handler.(ScheduleObserver); ok {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		observer.OnScheduled(at)
+	}
+}
+
+func (h *syncHandler) OnHookStart(runID int, phase domain.HookPhase) {
+	if observer, ok := h.handler.(HookObserver); ok {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		observer.OnHookStart(runID, phase)
+	}
+}
+
+func (h *syncHandler) OnHookComplete(runID int, result domain.HookResult) {
+	if observer, ok := h.handler.(HookObserver); ok {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		observer.OnHookComplete(runID, result)
+	}
+}
+
+func (h *syncHandler) OnWarmupStart(n int) {
+	if observer, ok := h.handler.(WarmupObserver); ok {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		observer.OnWarmupStart(n)
+	}
+}
+
+func (h *syncHandler) OnWarmupComplete(result domain.RunResult) {
+	if observer, ok := h.handler.(WarmupObserver); ok {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		observer.OnWarmupComplete(result)
+	}
+}
+
+func (h *syncHandler) GetWarmupWriters(n int) (stdout, stderr io.Writer) {
+	if observer, ok := h.handler.(WarmupObserver); ok {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		return observer.GetWarmupWriters(n)
+	}
+	return nil, nil
+}
+
+func (h *syncHandler) OnBaselineCheck(check domain.BaselineCheck) {
+	if observer, ok := h.handler.(BaselineObserver); ok {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		observer.OnBaselineCheck(check)
+	}
+}
+
+func (h *syncHandler) OnWarning(err error) {
+	observer, ok := h.handler.(WarningObserver)
+	if !ok {
+		fmt.Fprintln(os.Stderr, "Warning:", err)
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	observer.OnWarning(err)
+}
+
+func (h *syncHandler) GetOutputWriters(runID int) (stdout, stderr io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.handler.GetOutputWriters(runID)
+}
+
 func NewExecutor(cfg *domain.RunConfig, runner *infra.CommandRunner) Executor {
+	if cfg.Parallel > 1 {
+		return NewParallelExecutor(runner, cfg.Parallel)
+	}
 	return NewSequentialExecutor(runner)
 }
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"context"
+	"io"
+	"maps"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/msaeedsaeedi/again/internal/domain"
+	"github.com/msaeedsaeedi/again/internal/infra"
+)
+
+// recordingHandler keeps the status of every completed run.
+type recordingHandler struct {
+	statuses map[int]domain.RunStatus
+}
+
+func (h *recordingHandler) OnStart(int) {}
+func (h *recordingHandler) OnComplete(result domain.RunResult) {
+	h.statuses[result.ID] = result.Status
+}
+func (h *recordingHandler) OnFinish()                                   {}
+func (h *recordingHandler) GetOutputWriters(int) (io.Writer, io.Writer) { return nil, nil }
+
+func TestParallelExecutorHalt(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	tests := []struct {
+		name      string
+		command   string
+		afterEach string
+		want      map[int]domain.RunStatus
+	}{
+		{
+			name:    "run killed by the halt is skipped",
+			command: `[ "$AGAIN_RUN_ID" = 1 ] && exit 1; sleep 5`,
+			want:    map[int]domain.RunStatus{1: domain.StatusFailed, 2: domain.StatusSkipped},
+		},
+		{
+			// Run 2 fails first, but is only recorded once its after-each hook is cut short by run 1 halting
+			name:      "failure finished before the halt is kept",
+			command:   `[ "$AGAIN_RUN_ID" = 1 ] && sleep 0.2; exit 1`,
+			afterEach: `[ "$AGAIN_RUN_ID" = 1 ] || sleep 5`,
+			want:      map[int]domain.RunStatus{1: domain.StatusFailed, 2: domain.StatusFailed},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &domain.RunConfig{
+				Command:   []string{tt.command},
+				Times:     2,
+				Parallel:  2,
+				FailFast:  true,
+				AfterEach: tt.afterEach,
+			}
+			handler := &recordingHandler{statuses: make(map[int]domain.RunStatus)}
+
+			executor := NewParallelExecutor(infra.NewCommandRunner(), cfg.Parallel)
+			if err := executor.Execute(context.Background(), cfg, handler); err == nil {
+				t.Error("Execute() succeeded, want the failure threshold error")
+			}
+			if !maps.Equal(handler.statuses, tt.want) {
+				t.Errorf("statuses %v, want %v", handler.statuses, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecutorTimeBudgetInterruptsWait(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	executors := map[string]func(*domain.RunConfig) Executor{
+		"sequential": func(*domain.RunConfig) Executor { return NewSequentialExecutor(infra.NewCommandRunner()) },
+		"parallel": func(cfg *domain.RunConfig) Executor {
+			return NewParallelExecutor(infra.NewCommandRunner(), cfg.Parallel)
+		},
+	}
+
+	for name, newExecutor := range executors {
+		t.Run(name, func(t *testing.T) {
+			// Every start after the first waits an hour, which the time budget must cut short
+			cfg := &domain.RunConfig{
+				Command:    []string{"true"},
+				Parallel:   2,
+				TimeBudget: 200 * time.Millisecond,
+				Interval:   time.Hour,
+			}
+			handler := &recordingHandler{statuses: make(map[int]domain.RunStatus)}
+
+			start := time.Now()
+			if err := newExecutor(cfg).Execute(context.Background(), cfg, handler); err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if took := time.Since(start); took > 5*time.Second {
+				t.Errorf("Execute() took %v, want it to stop once the 200ms budget is spent", took)
+			}
+			if want := map[int]domain.RunStatus{1: domain.StatusSuccess}; !maps.Equal(handler.statuses, want) {
+				t.Errorf("statuses %v, want %v", handler.statuses, want)
+			}
+		})
+	}
+}
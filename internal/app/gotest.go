@@ -0,0 +1,72 @@
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/msaeedsaeedi/again/internal/domain"
+)
+
+// goTestEvent is one line of the go test -json event stream (see go doc test2json).
+type goTestEvent struct {
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64 // Seconds
+}
+
+// goTestParser reads the go test -json event stream.
+type goTestParser struct{}
+
+// Parse collects the final outcome of every test in a go test -json stream.
+// Lines that are not JSON events, such as build errors, are ignored. A package
+// that fails without any failing test, for example because it did not compile,
+// is reported as a failed domain.PackageTest.
+func (goTestParser) Parse(r io.Reader) ([]domain.TestOutcome, error) {
+	var tests []domain.TestOutcome
+	failedPackages := make(map[string]bool)
+	var packageFailures []string
+
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 && line[0] == '{' {
+			var event goTestEvent
+			if json.Unmarshal(line, &event) == nil {
+				switch status := domain.TestStatus(event.Action); status {
+				case domain.TestPass, domain.TestFail, domain.TestSkip:
+					if event.Test != "" {
+						tests = append(tests, domain.TestOutcome{
+							Package:  event.Package,
+							Name:     event.Test,
+							Status:   status,
+							Duration: time.Duration(event.Elapsed * float64(time.Second)),
+						})
+						if status == domain.TestFail {
+							failedPackages[event.Package] = true
+						}
+					} else if status == domain.TestFail {
+						packageFailures = append(packageFailures, event.Package)
+					}
+				}
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return tests, err
+		}
+	}
+
+	for _, pkg := range packageFailures {
+		if !failedPackages[pkg] {
+			tests = append(tests, domain.TestOutcome{Package: pkg, Name: domain.PackageTest, Status: domain.TestFail})
+		}
+	}
+	return tests, nil
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/msaeedsaeedi/again/internal/domain"
+)
+
+func TestGoTestParser(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream string
+		want   []domain.TestOutcome
+	}{
+		{
+			name:   "empty",
+			stream: "",
+		},
+		{
+			name: "final outcomes of tests and subtests",
+			stream: `{"Action":"start","Package":"example.com/a"}
+{"Action":"run","Package":"example.com/a","Test":"TestOK"}
+{"Action":"output","Package":"example.com/a","Test":"TestOK","Output":"=== RUN   TestOK\n"}
+{"Action":"pass","Package":"example.com/a","Test":"TestOK","Elapsed":0.25}
+{"Action":"run","Package":"example.com/a","Test":"TestFlaky/case_1"}
+{"Action":"fail","Package":"example.com/a","Test":"TestFlaky/case_1","Elapsed":1.5}
+{"Action":"fail","Package":"example.com/a","Test":"TestFlaky","Elapsed":1.5}
+{"Action":"skip","Package":"example.com/a","Test":"TestSlow"}
+{"Action":"fail","Package":"example.com/a","Elapsed":2}
+`,
+			want: []domain.TestOutcome{
+				{Package: "example.com/a", Name: "TestOK", Status: domain.TestPass, Duration: 250 * time.Millisecond},
+				{Package: "example.com/a", Name: "TestFlaky/case_1", Status: domain.TestFail, Duration: 1500 * time.Millisecond},
+				{Package: "example.com/a", Name: "TestFlaky", Status: domain.TestFail, Duration: 1500 * time.Millisecond},
+				{Package: "example.com/a", Name: "TestSlow", Status: domain.TestSkip},
+			},
+		},
+		{
+			name: "package failing without a failed test",
+			stream: `# example.com/b
+b.go:3:1: syntax error: non-declaration statement outside function body
+{"Action":"start","Package":"example.com/b"}
+{"Action":"output","Package":"example.com/b","Output":"FAIL\texample.com/b [build failed]\n"}
+{"Action":"fail","Package":"example.com/b","Elapsed":0}
+{"Action":"pass","Package":"example.com/c","Test":"TestC"}
+{"Action":"pass","Package":"example.com/c"}
+`,
+			want: []domain.TestOutcome{
+				{Package: "example.com/c", Name: "TestC", Status: domain.TestPass},
+				{Package: "example.com/b", Name: domain.PackageTest, Status: domain.TestFail},
+			},
+		},
+		{
+			name:   "malformed events and missing final newline",
+			stream: "{not json}\n{\"Action\":\"pass\",\"Package\":\"p\",\"Test\":\"TestLast\"}",
+			want: []domain.TestOutcome{
+				{Package: "p", Name: "TestLast", Status: domain.TestPass},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := goTestParser{}.Parse(strings.NewReader(tt.stream))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/msaeedsaeedi/again/internal/domain"
+	"github.com/msaeedsaeedi/again/internal/ui"
+)
+
+// Replay formats the runs of a recorded session as format, feeding them to the
+// formatter in the order they completed as if they were running again.
+func (o *Orchestrator) Replay(ctx context.Context, session domain.Session, format domain.OutputFormat) error {
+	if err := domain.ValidateFormat(format); err != nil {
+		return err
+	}
+
+	cfg := session.Config
+	cfg.Format = format
+	executor := replayExecutor{results: session.Results}
+	handler := getFormatter(&cfg)
+
+	if format == domain.FormatTUI {
+		tuiHandler, ok := handler.(*ui.TUIFormatter)
+		if !ok {
+			return fmt.Errorf("tui formatter not available")
+		}
+		return o.executeTUI(ctx, executor, &cfg, tuiHandler)
+	}
+	return executor.Execute(ctx, &cfg, handler)
+}
+
+// replayExecutor hands recorded results to a handler instead of running anything.
+type replayExecutor struct {
+	results []domain.RunResult
+}
+
+func (e replayExecutor) Execute(ctx context.Context, cfg *domain.RunConfig, handler ResultHandler) error {
+	for _, result := range e.results {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Skipped runs never started, so handlers only see them complete
+		if result.Status != domain.StatusSkipped {
+			handler.OnStart(result.ID)
+			stdout, stderr := handler.GetOutputWriters(result.ID)
+			if stdout != nil {
+				stdout.Write(result.Stdout)
+			}
+			if stderr != nil {
+				stderr.Write(result.Stderr)
+			}
+		}
+		handler.OnComplete(result)
+	}
+
+	handler.OnFinish()
+	return nil
+}
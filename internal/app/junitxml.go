@@ -0,0 +1,91 @@
+package app
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/msaeedsaeedi/again/internal/domain"
+)
+
+// junitCase is the part of a JUnit XML <testcase> that decides its outcome.
+type junitCase struct {
+	Name      string    `xml:"name,attr"`
+	ClassName string    `xml:"classname,attr"`
+	Time      string    `xml:"time,attr"`
+	Failure   *struct{} `xml:"failure"`
+	Error     *struct{} `xml:"error"`
+	Skipped   *struct{} `xml:"skipped"`
+}
+
+// junitParser reads JUnit XML reports. Test cases are found at any depth, so
+// both <testsuites> and bare <testsuite> documents work.
+type junitParser struct{}
+
+// Parse records one outcome per test case. Failures and errors count as failed.
+// Test cases without a classname belong to their innermost suite.
+func (junitParser) Parse(r io.Reader) ([]domain.TestOutcome, error) {
+	var tests []domain.TestOutcome
+	var suites []string
+
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return tests, nil
+		}
+		if err != nil {
+			return tests, err
+		}
+
+		switch el := token.(type) {
+		case xml.StartElement:
+			switch el.Name.Local {
+			case "testsuite":
+				suites = append(suites, junitAttr(el, "name"))
+			case "testcase":
+				var tc junitCase
+				if err := decoder.DecodeElement(&tc, &el); err != nil {
+					return tests, err
+				}
+				tests = append(tests, newJUnitOutcome(tc, suites))
+			}
+		case xml.EndElement:
+			if el.Name.Local == "testsuite" && len(suites) > 0 {
+				suites = suites[:len(suites)-1]
+			}
+		}
+	}
+}
+
+func newJUnitOutcome(tc junitCase, suites []string) domain.TestOutcome {
+	outcome := domain.TestOutcome{Package: tc.ClassName, Name: tc.Name, Status: domain.TestPass}
+	if outcome.Package == "" && len(suites) > 0 {
+		outcome.Package = suites[len(suites)-1]
+	}
+
+	switch {
+	case tc.Failure != nil || tc.Error != nil:
+		outcome.Status = domain.TestFail
+	case tc.Skipped != nil:
+		outcome.Status = domain.TestSkip
+	}
+
+	// Some reporters group thousands, as in "1,234.5"
+	if seconds, err := strconv.ParseFloat(strings.ReplaceAll(tc.Time, ",", ""), 64); err == nil {
+		outcome.Duration = time.Duration(seconds * float64(time.Second))
+	}
+	return outcome
+}
+
+func junitAttr(el xml.StartElement, name string) string {
+	for _, attr := range el.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/msaeedsaeedi/again/internal/domain"
+)
+
+func TestJUnitParser(t *testing.T) {
+	tests := []struct {
+		name    string
+		report  string
+		want    []domain.TestOutcome
+		wantErr bool
+	}{
+		{
+			name:   "empty",
+			report: "",
+		},
+		{
+			name: "outcomes of every case",
+			report: `<?xml version="1.0" encoding="utf-8"?>
+<testsuites>
+  <testsuite name="pytest" tests="4">
+    <testcase classname="tests.test_api" name="test_get" time="0.012"/>
+    <testcase classname="tests.test_api" name="test_post" time="1,234.5">
+      <failure message="assert 500 == 200">Traceback</failure>
+    </testcase>
+    <testcase classname="tests.test_db" name="test_connect" time="0.5">
+      <error message="connection refused"/>
+    </testcase>
+    <testcase classname="tests.test_db" name="test_migrate">
+      <skipped message="no database"/>
+    </testcase>
+  </testsuite>
+</testsuites>`,
+			want: []domain.TestOutcome{
+				{Package: "tests.test_api", Name: "test_get", Status: domain.TestPass, Duration: 12 * time.Millisecond},
+				{Package: "tests.test_api", Name: "test_post", Status: domain.TestFail, Duration: 1234500 * time.Millisecond},
+				{Package: "tests.test_db", Name: "test_connect", Status: domain.TestFail, Duration: 500 * time.Millisecond},
+				{Package: "tests.test_db", Name: "test_migrate", Status: domain.TestSkip},
+			},
+		},
+		{
+			name: "cases without a classname belong to their innermost suite",
+			report: `<testsuite name="outer">
+  <testcase name="first"/>
+  <testsuite name="inner">
+    <testcase name="second"/>
+  </testsuite>
+  <testcase name="third"/>
+</testsuite>`,
+			want: []domain.TestOutcome{
+				{Package: "outer", Name: "first", Status: domain.TestPass},
+				{Package: "inner", Name: "second", Status: domain.TestPass},
+				{Package: "outer", Name: "third", Status: domain.TestPass},
+			},
+		},
+		{
+			name:    "malformed XML",
+			report:  `<testsuite name="s"><testcase name="a"></testsuite>`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := junitParser{}.Parse(strings.NewReader(tt.report))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Parse() = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 
 	"golang.org/x/sync/errgroup"
 
@@ -60,29 +61,46 @@ func (o *Orchestrator) executeTUI(ctx context.Context, executor Executor, cfg *d
 		return err
 	}
 
-	// Start executor
+	// Start executor; a stop based on run outcomes keeps the TUI open and is reported after it exits
+	var stopErr error
 	g.Go(func() error {
 		if err := executor.Execute(gctx, cfg, tui); err != nil {
 			if errors.Is(err, context.Canceled) {
 				return nil
 			}
+			if IsRunOutcome(err) {
+				stopErr = err
+				return nil
+			}
 			return err
 		}
 		return nil
 	})
 
-	if err := g.Wait(); err != nil {
+	err := g.Wait()
+	tui.WriteWarnings(os.Stderr)
+	if err != nil {
 		return err
 	}
-	return nil
+	return stopErr
+}
+
+// IsRunOutcome reports whether err describes how the runs went rather than a usage or execution problem.
+func IsRunOutcome(err error) bool {
+	return errors.Is(err, ErrFailureThreshold) || errors.Is(err, ErrFailureFound) || errors.Is(err, ErrUntilNotMet) ||
+		errors.Is(err, ErrHookFailed) || errors.Is(err, ErrRegression)
 }
 
 func getFormatter(cfg *domain.RunConfig) ResultHandler {
 	switch cfg.Format {
 	case domain.FormatRaw:
-		return ui.NewRawFormatter()
+		return ui.NewRawFormatter(cfg)
 	case domain.FormatJSON:
 		return ui.NewJSONFormatter(cfg)
+	case domain.FormatNDJSON:
+		return ui.NewNDJSONFormatter(cfg)
+	case domain.FormatJUnit:
+		return ui.NewJUnitFormatter(cfg)
 	case domain.FormatTUI:
 		return ui.NewTUIFormatter(cfg)
 	default:
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/msaeedsaeedi/again/internal/domain"
+)
+
+// TestParser turns the test report of one run into test case outcomes.
+type TestParser interface {
+	Parse(r io.Reader) ([]domain.TestOutcome, error)
+}
+
+// NewTestParser returns the parser for kind, or nil when parsing is disabled.
+func NewTestParser(kind domain.ParserKind) (TestParser, error) {
+	switch kind {
+	case domain.ParserNone:
+		return nil, nil
+	case domain.ParserGoTest:
+		return goTestParser{}, nil
+	case domain.ParserTAP:
+		return tapParser{}, nil
+	case domain.ParserJUnit:
+		return junitParser{}, nil
+	default:
+		return nil, fmt.Errorf("invalid parser: %s", kind)
+	}
+}
+
+// openReport opens the report a run produced: the file configured with
+// --parse-file, or its stdout. It returns nil when the run wrote no report,
+// including when the file is left over from before the run started.
+func openReport(cfg *domain.RunConfig, info domain.RunInfo, result domain.RunResult) (io.ReadCloser, error) {
+	if cfg.ParseFile == "" {
+		return result.OpenStdout()
+	}
+
+	path, err := domain.ExpandCommand([]string{cfg.ParseFile}, info)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path[0])
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Modification times can be as coarse as a second
+	if stat, err := f.Stat(); err == nil && stat.ModTime().Before(result.StartedAt.Truncate(time.Second)) {
+		f.Close()
+		return nil, nil
+	}
+	return f, nil
+}
@@ -0,0 +1,28 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/msaeedsaeedi/again/internal/domain"
+)
+
+func TestNewTestParser(t *testing.T) {
+	tests := []struct {
+		kind    domain.ParserKind
+		want    TestParser
+		wantErr bool
+	}{
+		{domain.ParserNone, nil, false},
+		{domain.ParserGoTest, goTestParser{}, false},
+		{domain.ParserTAP, tapParser{}, false},
+		{domain.ParserJUnit, junitParser{}, false},
+		{"xunit", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := NewTestParser(tt.kind)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("NewTestParser(%q) = %T, %v, want %T", tt.kind, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package app
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/msaeedsaeedi/again/internal/domain"
+)
+
+// ScheduleObserver is implemented by handlers that display when the next run will start.
+type ScheduleObserver interface {
+	OnScheduled(at time.Time)
+}
+
+// tokenBucket is a reservation-based token bucket: tokens may go negative, and the
+// debt tells the caller how long to wait before its reservation is honoured.
+type tokenBucket struct {
+	rate   float64 // Tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// reserve takes one token at now and returns how long to wait until it is available.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	if !b.last.IsZero() {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+	b.tokens--
+
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// startScheduler paces run starts according to the interval, jitter and rate settings.
+// Reservations are handed out in order, so workers sharing it never start closer together
+// than configured.
+type startScheduler struct {
+	interval time.Duration
+	jitter   time.Duration
+	bucket   *tokenBucket
+	observer ScheduleObserver
+	mu       sync.Mutex
+	next     time.Time
+}
+
+// newStartScheduler returns nil when no pacing is configured.
+func newStartScheduler(cfg *domain.RunConfig, handler ResultHandler) *startScheduler {
+	if cfg.Interval == 0 && cfg.Jitter == 0 && cfg.Rate == 0 {
+		return nil
+	}
+
+	s := &startScheduler{
+		interval: cfg.Interval,
+		jitter:   cfg.Jitter,
+	}
+	if cfg.Rate > 0 {
+		s.bucket = newTokenBucket(cfg.Rate, 1)
+	}
+	if observer, ok := handler.(ScheduleObserver); ok {
+		s.observer = observer
+	}
+	return s
+}
+
+func (s *startScheduler) reserve(now time.Time) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	at := now
+	if s.next.After(at) {
+		at = s.next
+	}
+	if s.bucket != nil {
+		at = at.Add(s.bucket.reserve(at))
+	}
+
+	gap := s.interval
+	if s.jitter > 0 {
+		gap += rand.N(s.jitter)
+	}
+	s.next = at.Add(gap)
+
+	return at
+}
+
+// Wait blocks until the caller's reserved start time or until ctx is done.
+func (s *startScheduler) Wait(ctx context.Context) error {
+	if s == nil {
+		return ctx.Err()
+	}
+
+	at := s.reserve(time.Now())
+	if s.observer != nil {
+		s.observer.OnScheduled(at)
+	}
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/msaeedsaeedi/again/internal/domain"
+)
+
+// tapParser reads Test Anything Protocol output. Only top-level test points are
+// recorded; indented subtests are summarized by their parent's result.
+type tapParser struct{}
+
+// Parse records one outcome per "ok" or "not ok" line. Tests with a SKIP
+// directive, and failing tests with a TODO directive, count as skipped. A
+// "Bail out!" is reported as a failed domain.PackageTest.
+func (tapParser) Parse(r io.Reader) ([]domain.TestOutcome, error) {
+	var tests []domain.TestOutcome
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if strings.HasPrefix(line, "Bail out!") {
+			tests = append(tests, domain.TestOutcome{Name: domain.PackageTest, Status: domain.TestFail})
+			break
+		}
+
+		status := domain.TestPass
+		rest, ok := strings.CutPrefix(line, "ok")
+		if !ok {
+			if rest, ok = strings.CutPrefix(line, "not ok"); !ok {
+				continue
+			}
+			status = domain.TestFail
+		}
+		// "okay" and similar are not test points
+		if rest != "" && rest[0] != ' ' {
+			continue
+		}
+
+		number, description := tapTestPoint(rest)
+		description, directive := tapDirective(description)
+		switch {
+		case strings.HasPrefix(directive, "SKIP"):
+			status = domain.TestSkip
+		case strings.HasPrefix(directive, "TODO") && status == domain.TestFail:
+			status = domain.TestSkip
+		}
+
+		name := description
+		if name == "" {
+			name = fmt.Sprintf("test %s", number)
+		}
+		tests = append(tests, domain.TestOutcome{Name: name, Status: status})
+	}
+	return tests, scanner.Err()
+}
+
+// tapTestPoint splits what follows "ok" into the optional test number and the description.
+func tapTestPoint(rest string) (number, description string) {
+	rest = strings.TrimSpace(rest)
+	end := strings.IndexFunc(rest, func(r rune) bool { return r < '0' || r > '9' })
+	if end < 0 {
+		end = len(rest)
+	}
+	number, rest = rest[:end], strings.TrimSpace(rest[end:])
+	rest, _ = strings.CutPrefix(rest, "- ")
+	if rest == "-" {
+		rest = ""
+	}
+	return number, strings.TrimSpace(rest)
+}
+
+// tapDirective separates a "# SKIP" or "# TODO" directive from the description.
+// Escaped hashes ("\#") belong to the description.
+func tapDirective(description string) (string, string) {
+	for i := 0; i < len(description); i++ {
+		switch description[i] {
+		case '\\':
+			i++
+		case '#':
+			directive := strings.ToUpper(strings.TrimSpace(description[i+1:]))
+			return strings.TrimSpace(description[:i]), directive
+		}
+	}
+	return description, ""
+}
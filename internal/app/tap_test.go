@@ -0,0 +1,68 @@
+package app
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/msaeedsaeedi/again/internal/domain"
+)
+
+func TestTAPParser(t *testing.T) {
+	pass := func(name string) domain.TestOutcome { return domain.TestOutcome{Name: name, Status: domain.TestPass} }
+	fail := func(name string) domain.TestOutcome { return domain.TestOutcome{Name: name, Status: domain.TestFail} }
+	skip := func(name string) domain.TestOutcome { return domain.TestOutcome{Name: name, Status: domain.TestSkip} }
+
+	tests := []struct {
+		name   string
+		report string
+		want   []domain.TestOutcome
+	}{
+		{
+			name:   "empty",
+			report: "",
+		},
+		{
+			name:   "test points with and without numbers and dashes",
+			report: "TAP version 13\n1..4\nok 1 - adds numbers\nnot ok 2 subtracts numbers\nok - divides\nok 4\n",
+			want:   []domain.TestOutcome{pass("adds numbers"), fail("subtracts numbers"), pass("divides"), pass("test 4")},
+		},
+		{
+			name:   "directives",
+			report: "ok 1 - network # SKIP offline\nnot ok 2 - unicode # TODO not implemented\nok 3 - parser # todo done early\nnot ok 4 - cache # skip\n",
+			want:   []domain.TestOutcome{skip("network"), skip("unicode"), pass("parser"), skip("cache")},
+		},
+		{
+			name:   "escaped hash belongs to the description",
+			report: "ok 1 - handles \\# in names\n",
+			want:   []domain.TestOutcome{pass(`handles \# in names`)},
+		},
+		{
+			name:   "indented subtests, diagnostics and YAML blocks are ignored",
+			report: "# Subtest: suite\n    ok 1 - inner\n    1..1\nok 1 - suite\n  ---\n  duration_ms: 3\n  ...\n# tests 1\nokay then\n",
+			want:   []domain.TestOutcome{pass("suite")},
+		},
+		{
+			name:   "bail out stops parsing",
+			report: "ok 1 - first\nBail out! database unavailable\nok 2 - never reached\n",
+			want:   []domain.TestOutcome{pass("first"), fail(domain.PackageTest)},
+		},
+		{
+			name:   "windows line endings",
+			report: "ok 1 - first\r\nnot ok 2 - second\r\n",
+			want:   []domain.TestOutcome{pass("first"), fail("second")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tapParser{}.Parse(strings.NewReader(tt.report))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
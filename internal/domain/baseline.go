@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// Significance is the p-value below which a difference from the baseline is reported.
+const Significance = 0.05
+
+// Baseline is a saved duration distribution that later sessions are checked against.
+type Baseline struct {
+	Command     []string
+	CreatedAt   time.Time
+	Executed    int
+	Succeeded   int
+	SuccessRate float64
+	Durations   []time.Duration // Measured durations, in completion order
+}
+
+// NewBaseline captures the measured runs of a session.
+func NewBaseline(command []string, stats *StatsCollector) Baseline {
+	summary := stats.Summary()
+	return Baseline{
+		Command:     command,
+		CreatedAt:   time.Now(),
+		Executed:    summary.Executed,
+		Succeeded:   summary.Succeeded,
+		SuccessRate: summary.SuccessRate,
+		Durations:   stats.Durations(),
+	}
+}
+
+type Verdict string
+
+const (
+	VerdictRegression   Verdict = "regression"
+	VerdictImprovement  Verdict = "improvement"
+	VerdictUnchanged    Verdict = "unchanged"
+	VerdictInconclusive Verdict = "inconclusive" // Too few runs on either side to test
+)
+
+// BaselineCheck is the outcome of testing a session against a baseline.
+type BaselineCheck struct {
+	Baseline    Baseline
+	Current     DurationStats
+	Previous    DurationStats
+	SuccessRate float64
+	Change      float64 // Percentage change of the mean duration, positive when slower
+	Threshold   float64 // Percentage slowdown tolerated before a regression is reported
+	// PValue is the one-sided p-value of the test in the direction of the verdict
+	PValue  float64
+	Verdict Verdict
+}
+
+// Regressed reports whether the session is significantly slower than the baseline allows.
+func (c BaselineCheck) Regressed() bool {
+	return c.Verdict == VerdictRegression
+}
+
+// CheckBaseline runs Welch's t-test on the session's durations against the baseline's.
+// A regression means the mean is significantly above the baseline mean raised by
+// threshold percent; an improvement means it is significantly below the baseline
+// mean lowered by the same margin.
+func CheckBaseline(baseline Baseline, stats *StatsCollector, threshold float64) BaselineCheck {
+	summary := stats.Summary()
+	check := BaselineCheck{
+		Baseline:    baseline,
+		Current:     summary.Durations,
+		Previous:    ComputeDurationStats(baseline.Durations),
+		SuccessRate: summary.SuccessRate,
+		Threshold:   threshold,
+		Verdict:     VerdictInconclusive,
+		PValue:      1,
+	}
+
+	if check.Previous.Mean > 0 {
+		check.Change = (float64(check.Current.Mean)/float64(check.Previous.Mean) - 1) * 100
+	}
+	if check.Current.Count < 2 || check.Previous.Count < 2 {
+		return check
+	}
+
+	current := stats.Durations()
+	slower := WelchTTest(baseline.Durations, 1+threshold/100, current)
+	faster := WelchTTest(current, 1/(1-threshold/100), baseline.Durations)
+
+	switch {
+	case slower < Significance:
+		check.Verdict, check.PValue = VerdictRegression, slower
+	case faster < Significance:
+		check.Verdict, check.PValue = VerdictImprovement, faster
+	default:
+		check.Verdict, check.PValue = VerdictUnchanged, slower
+	}
+	return check
+}
+
+// WelchTTest returns the one-sided p-value for the hypothesis that the mean of b is
+// greater than the mean of a scaled by factor, without assuming equal variances.
+func WelchTTest(a []time.Duration, factor float64, b []time.Duration) float64 {
+	meanA, varA := sampleMoments(a)
+	meanB, varB := sampleMoments(b)
+	meanA *= factor
+	varA *= factor * factor
+
+	na, nb := float64(len(a)), float64(len(b))
+	seA, seB := varA/na, varB/nb
+	se := seA + seB
+	if se == 0 {
+		if meanB > meanA {
+			return 0
+		}
+		return 1
+	}
+
+	t := (meanB - meanA) / math.Sqrt(se)
+	df := se * se / (seA*seA/(na-1) + seB*seB/(nb-1))
+	return 1 - StudentTCDF(t, df)
+}
+
+// sampleMoments returns the mean and unbiased variance of durations in nanoseconds.
+func sampleMoments(durations []time.Duration) (mean, variance float64) {
+	for _, d := range durations {
+		mean += float64(d)
+	}
+	mean /= float64(len(durations))
+
+	for _, d := range durations {
+		diff := float64(d) - mean
+		variance += diff * diff
+	}
+	return mean, variance / float64(len(durations)-1)
+}
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestWelchTTest(t *testing.T) {
+	// Reference p-values integrate the t density numerically
+	tests := []struct {
+		name   string
+		a      []time.Duration
+		factor float64
+		b      []time.Duration
+		want   float64
+	}{
+		{"b slower with unequal variances", ms(1, 2, 3, 4, 5), 1, ms(2, 4, 6, 8, 10), 0.0537656},
+		{"a scaled by a threshold", ms(10, 11, 12, 13, 14), 1.05, ms(12, 13, 14, 15, 16), 0.1046764},
+		{"equal means", ms(10, 12, 11, 13, 12, 11), 1, ms(10, 11, 12, 11, 13, 12), 0.5},
+		{"b faster", ms(2, 4, 6, 8, 10), 1, ms(1, 2, 3, 4, 5), 1 - 0.0537656},
+		{"constant samples, b slower", ms(5, 5, 5), 1, ms(6, 6, 6), 0},
+		{"constant samples, b not slower", ms(5, 5, 5), 1, ms(5, 5, 5), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WelchTTest(tt.a, tt.factor, tt.b); math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("WelchTTest() = %.7f, want %.7f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckBaseline(t *testing.T) {
+	collect := func(durations []time.Duration) *StatsCollector {
+		c := NewStatsCollector()
+		for _, d := range durations {
+			c.Add(RunResult{Status: StatusSuccess, Success: true, Duration: d})
+		}
+		return c
+	}
+	stable := ms(100, 101, 99, 100, 102, 98, 100, 101, 99, 100)
+
+	tests := []struct {
+		name      string
+		baseline  []time.Duration
+		current   []time.Duration
+		threshold float64
+		want      Verdict
+	}{
+		{"same distribution", stable, stable, 5, VerdictUnchanged},
+		{"twice as slow", stable, ms(200, 202, 198, 200, 204, 196, 200, 202, 198, 200), 5, VerdictRegression},
+		{"twice as fast", stable, ms(50, 51, 49, 50, 51, 49, 50, 51, 49, 50), 5, VerdictImprovement},
+		{"slower within the threshold", stable, ms(103, 104, 102, 103, 105, 101, 103, 104, 102, 103), 5, VerdictUnchanged},
+		{"slower beyond a zero threshold", stable, ms(103, 104, 102, 103, 105, 101, 103, 104, 102, 103), 0, VerdictRegression},
+		{"single current run", stable, ms(500), 5, VerdictInconclusive},
+		{"single baseline run", ms(100), stable, 5, VerdictInconclusive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseline := NewBaseline([]string{"bench"}, collect(tt.baseline))
+			check := CheckBaseline(baseline, collect(tt.current), tt.threshold)
+			if check.Verdict != tt.want {
+				t.Errorf("verdict %s (p=%.4f, change %.1f%%), want %s", check.Verdict, check.PValue, check.Change, tt.want)
+			}
+			if check.Regressed() != (tt.want == VerdictRegression) {
+				t.Errorf("Regressed() = %v with verdict %s", check.Regressed(), check.Verdict)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// ConfidenceLevel is the coverage of the confidence intervals reported for compared commands.
+const ConfidenceLevel = 0.95
+
+// Comparing reports whether several commands are benchmarked against each other.
+func (c *RunConfig) Comparing() bool {
+	return len(c.Commands) > 1
+}
+
+// CommandCount returns how many commands the session runs.
+func (c *RunConfig) CommandCount() int {
+	if c.Comparing() {
+		return len(c.Commands)
+	}
+	return 1
+}
+
+// TotalRuns returns the planned number of measured runs across all commands,
+// which in open-ended sessions is the optional cap.
+func (c *RunConfig) TotalRuns() int {
+	return c.Times * c.CommandCount()
+}
+
+// TotalWarmups returns the number of warmup iterations across all commands.
+func (c *RunConfig) TotalWarmups() int {
+	return c.Warmup * c.CommandCount()
+}
+
+// CommandFor returns the command at a 1-based comparison index, or the only command for index 0.
+func (c *RunConfig) CommandFor(index int) []string {
+	if index < 1 || index > len(c.Commands) {
+		return c.Command
+	}
+	return c.Commands[index-1]
+}
+
+// CommandIndex returns which compared command the measured run id executes, 0 when not comparing.
+func (c *RunConfig) CommandIndex(id int) int {
+	index, _ := c.slot(id, c.Times)
+	return index
+}
+
+// WarmupCommandIndex returns which compared command the n-th warmup executes, 0 when not comparing.
+func (c *RunConfig) WarmupCommandIndex(n int) int {
+	index, _ := c.slot(n, c.Warmup)
+	return index
+}
+
+// slot maps the n-th iteration of a sequence in which every command runs perCommand
+// times to a 1-based command index and that command's own iteration number. Commands
+// run one after another, or take turns when interleaved.
+func (c *RunConfig) slot(n, perCommand int) (index, attempt int) {
+	if !c.Comparing() || n < 1 || perCommand < 1 {
+		return 0, n
+	}
+
+	count := len(c.Commands)
+	if c.Interleave {
+		return (n-1)%count + 1, (n-1)/count + 1
+	}
+	return (n-1)/perCommand + 1, (n-1)%perCommand + 1
+}
+
+// Interval is a two-sided confidence interval.
+type Interval struct {
+	Low  time.Duration
+	High time.Duration
+}
+
+// CommandStats summarizes the runs of one compared command.
+type CommandStats struct {
+	Index   int // 1-based position on the command line
+	Command []string
+	Summary Summary
+	MeanCI  Interval // Confidence interval of the mean duration at ConfidenceLevel
+	// Relative is the mean duration divided by the fastest command's, so the fastest is 1
+	Relative       float64
+	RelativeStdDev float64 // Uncertainty of Relative, 0 for the fastest command
+}
+
+// Comparison ranks compared commands by mean duration.
+type Comparison struct {
+	Commands []CommandStats
+	Fastest  int // Index of the command with the lowest mean, 0 until a run was measured
+}
+
+// FastestStats returns the fastest command, or false until a run was measured.
+func (c Comparison) FastestStats() (CommandStats, bool) {
+	if c.Fastest < 1 {
+		return CommandStats{}, false
+	}
+	return c.Commands[c.Fastest-1], true
+}
+
+// ComparisonCollector accumulates results of a comparison session per command.
+type ComparisonCollector struct {
+	cfg        *RunConfig
+	collectors []*StatsCollector
+}
+
+func NewComparisonCollector(cfg *RunConfig) *ComparisonCollector {
+	collectors := make([]*StatsCollector, len(cfg.Commands))
+	for i := range collectors {
+		collectors[i] = NewStatsCollector()
+	}
+	return &ComparisonCollector{cfg: cfg, collectors: collectors}
+}
+
+// Add records result for its command; results outside a comparison are ignored.
+func (c *ComparisonCollector) Add(result RunResult) {
+	if result.CommandIndex < 1 || result.CommandIndex > len(c.collectors) {
+		return
+	}
+	c.collectors[result.CommandIndex-1].Add(result)
+}
+
+func (c *ComparisonCollector) Comparison() Comparison {
+	var cmp Comparison
+	for i, collector := range c.collectors {
+		summary := collector.Summary()
+		cmp.Commands = append(cmp.Commands, CommandStats{
+			Index:   i + 1,
+			Command: c.cfg.Commands[i],
+			Summary: summary,
+			MeanCI:  MeanConfidenceInterval(summary.Durations, ConfidenceLevel),
+		})
+
+		d := summary.Durations
+		if d.Count > 0 && (cmp.Fastest == 0 || d.Mean < cmp.Commands[cmp.Fastest-1].Summary.Durations.Mean) {
+			cmp.Fastest = i + 1
+		}
+	}
+
+	fastest, ok := cmp.FastestStats()
+	if !ok || fastest.Summary.Durations.Mean <= 0 {
+		return cmp
+	}
+
+	// Relative uncertainty is propagated from both means' coefficients of variation
+	base := fastest.Summary.Durations
+	baseCV := float64(base.StdDev) / float64(base.Mean)
+	for i := range cmp.Commands {
+		d := cmp.Commands[i].Summary.Durations
+		if d.Count == 0 {
+			continue
+		}
+		ratio := float64(d.Mean) / float64(base.Mean)
+		cmp.Commands[i].Relative = ratio
+		if cmp.Commands[i].Index != cmp.Fastest && d.Mean > 0 {
+			cv := float64(d.StdDev) / float64(d.Mean)
+			cmp.Commands[i].RelativeStdDev = ratio * math.Sqrt(cv*cv+baseCV*baseCV)
+		}
+	}
+	return cmp
+}
+
+// Compare builds a Comparison from a complete set of results.
+func Compare(cfg *RunConfig, results []RunResult) Comparison {
+	c := NewComparisonCollector(cfg)
+	for _, result := range results {
+		c.Add(result)
+	}
+	return c.Comparison()
+}
+
+// MeanConfidenceInterval estimates where the true mean lies using Student's t-distribution.
+// With fewer than two samples the interval collapses to the mean; it never extends below zero.
+func MeanConfidenceInterval(d DurationStats, level float64) Interval {
+	if d.Count < 2 {
+		return Interval{Low: d.Mean, High: d.Mean}
+	}
+
+	t := StudentTQuantile(1-(1-level)/2, float64(d.Count-1))
+	half := time.Duration(t * float64(d.StdDev) / math.Sqrt(float64(d.Count)))
+	return Interval{Low: max(0, d.Mean-half), High: d.Mean + half}
+}
@@ -0,0 +1,44 @@
+package domain
+
+import "fmt"
+
+// ConfigSource locates a setting that was loaded from a configuration file.
+type ConfigSource struct {
+	File string
+	Line int
+}
+
+func (s ConfigSource) String() string {
+	return fmt.Sprintf("%s:%d", s.File, s.Line)
+}
+
+// ConfigSources maps option names, as spelled on the command line, to the file
+// line that set them. Options given as flags or left at their default are absent.
+type ConfigSources map[string]ConfigSource
+
+// Wrap prefixes err with the location of option when it came from a configuration file.
+func (s ConfigSources) Wrap(option string, err error) error {
+	if err == nil {
+		return nil
+	}
+	src, ok := s[option]
+	if !ok {
+		return err
+	}
+	return &OptionError{Option: option, Source: src, Err: err}
+}
+
+// OptionError reports an invalid setting together with where it was configured.
+type OptionError struct {
+	Option string
+	Source ConfigSource
+	Err    error
+}
+
+func (e *OptionError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Source, e.Err)
+}
+
+func (e *OptionError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// SuccessCriteria decides whether a run that exited on its own counts as a success.
+type SuccessCriteria struct {
+	codes  []int
+	expect *regexp.Regexp
+	reject *regexp.Regexp
+}
+
+func NewSuccessCriteria(cfg *RunConfig) (*SuccessCriteria, error) {
+	c := &SuccessCriteria{codes: cfg.SuccessCodes}
+	if len(c.codes) == 0 {
+		c.codes = []int{0}
+	}
+
+	for _, code := range c.codes {
+		if code < 0 || code > 255 {
+			return nil, cfg.Sources.Wrap("success-codes", fmt.Errorf("invalid success code: %d", code))
+		}
+	}
+
+	var err error
+	if cfg.ExpectStdout != "" {
+		if c.expect, err = regexp.Compile(cfg.ExpectStdout); err != nil {
+			return nil, cfg.Sources.Wrap("expect-stdout", fmt.Errorf("invalid expect-stdout pattern: %w", err))
+		}
+	}
+	if cfg.RejectOutput != "" {
+		if c.reject, err = regexp.Compile(cfg.RejectOutput); err != nil {
+			return nil, cfg.Sources.Wrap("reject-output", fmt.Errorf("invalid reject-output pattern: %w", err))
+		}
+	}
+
+	return c, nil
+}
+
+// Custom reports whether anything beyond "exit code 0" was configured.
+func (c *SuccessCriteria) Custom() bool {
+	return !slices.Equal(c.codes, []int{0}) || c.expect != nil || c.reject != nil
+}
+
+// Apply re-evaluates result and records the reason for the verdict.
+// Signals, timeouts, cancellations and start errors always remain failures.
+func (c *SuccessCriteria) Apply(result *RunResult) {
+	if !c.Custom() {
+		return
+	}
+	if result.Status != StatusSuccess && result.Failure != FailureExitNonZero {
+		return
+	}
+
+	if !slices.Contains(c.codes, result.ExitCode) {
+		c.fail(result, FailureExitNonZero, fmt.Sprintf("exit code %d not in accepted codes %s", result.ExitCode, formatCodes(c.codes)))
+		return
+	}
+
+	stdout := result.StdoutBytes()
+	if c.expect != nil && !c.expect.Match(stdout) {
+		c.fail(result, FailureAssertion, fmt.Sprintf("stdout did not match /%s/", c.expect))
+		return
+	}
+
+	if c.reject != nil {
+		for _, output := range [][]byte{stdout, result.StderrBytes()} {
+			if match := c.reject.Find(output); match != nil {
+				c.fail(result, FailureAssertion, fmt.Sprintf("output matched rejected pattern /%s/: %q", c.reject, truncate(string(match), 60)))
+				return
+			}
+		}
+	}
+
+	reasons := []string{fmt.Sprintf("exit code %d accepted", result.ExitCode)}
+	if c.expect != nil {
+		reasons = append(reasons, fmt.Sprintf("stdout matched /%s/", c.expect))
+	}
+	if c.reject != nil {
+		reasons = append(reasons, fmt.Sprintf("no output matched /%s/", c.reject))
+	}
+
+	result.Success = true
+	result.Status = StatusSuccess
+	result.Failure = FailureNone
+	result.Error = nil
+	result.Reason = strings.Join(reasons, ", ")
+}
+
+func (c *SuccessCriteria) fail(result *RunResult, kind FailureKind, reason string) {
+	result.Success = false
+	result.Status = StatusFailed
+	result.Failure = kind
+	result.Reason = reason
+}
+
+func formatCodes(codes []int) string {
+	parts := make([]string, len(codes))
+	for i, code := range codes {
+		parts[i] = fmt.Sprint(code)
+	}
+	return strings.Join(parts, ",")
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,263 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DiffContext is the number of unchanged lines shown around each change.
+const DiffContext = 3
+
+// maxDiffEdits bounds the work spent on very different inputs; beyond it the
+// differing lines are reported as replaced wholesale.
+const maxDiffEdits = 2000
+
+type DiffKind byte
+
+const (
+	DiffEqual  DiffKind = ' '
+	DiffDelete DiffKind = '-'
+	DiffInsert DiffKind = '+'
+)
+
+// DiffLine is one line of an edit script turning one text into another.
+type DiffLine struct {
+	Kind DiffKind
+	Text string
+}
+
+// DiffHunk is a group of nearby changes with their surrounding context.
+// Line numbers are 1-based, as in unified diffs.
+type DiffHunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []DiffLine
+}
+
+// Header returns the hunk's range line, such as "@@ -3,7 +3,6 @@".
+func (h DiffHunk) Header() string {
+	return fmt.Sprintf("@@ -%s +%s @@", hunkRange(h.OldStart, h.OldLines), hunkRange(h.NewStart, h.NewLines))
+}
+
+func hunkRange(start, lines int) string {
+	// An empty range names the line before it, as GNU diff does
+	if lines == 0 {
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if lines == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, lines)
+}
+
+// DiffLines returns a shortest edit script turning a into b, using Myers' algorithm.
+func DiffLines(a, b []string) []DiffLine {
+	return appendDiff(nil, a, b)
+}
+
+// appendDiff appends the edit script turning a into b to script.
+func appendDiff(script []DiffLine, a, b []string) []DiffLine {
+	// Common prefixes and suffixes are cheap to strip and common in logs
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	for _, line := range a[:prefix] {
+		script = append(script, DiffLine{Kind: DiffEqual, Text: line})
+	}
+	oldMid, newMid := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(oldMid) == 0 || len(newMid) == 0 {
+		script = append(script, replaceLines(oldMid, newMid)...)
+	} else if x, y, ok := middleSnake(oldMid, newMid); ok {
+		// A shortest path passes through (x, y), so both halves are diffed on their own
+		script = appendDiff(script, oldMid[:x], newMid[:y])
+		script = appendDiff(script, oldMid[x:], newMid[y:])
+	} else {
+		script = append(script, replaceLines(oldMid, newMid)...)
+	}
+	for _, line := range a[len(a)-suffix:] {
+		script = append(script, DiffLine{Kind: DiffEqual, Text: line})
+	}
+	return script
+}
+
+// middleSnake finds a point (x, y) on a shortest path from the start of a and b
+// to their end, searching from both ends at once along diagonals k = x - y and
+// keeping only the furthest x reached on each one. It reports false when a and b
+// have nothing in common or the search exceeds maxDiffEdits, so the space it
+// takes is bounded by maxDiffEdits whatever the size of the input.
+func middleSnake(a, b []string) (x, y int, ok bool) {
+	n, m := len(a), len(b)
+	// Each direction covers half of the edits
+	limit := min((n+m+1)/2, maxDiffEdits/2+1)
+	offset := limit
+	// forward[offset+k] is the furthest x on diagonal k from the start; backward
+	// counts from the end, on diagonals of the reversed texts
+	forward := make([]int, 2*limit+2)
+	backward := make([]int, 2*limit+2)
+	for i := range forward {
+		forward[i], backward[i] = -1, -1
+	}
+	forward[offset+1], backward[offset+1] = 0, 0
+
+	delta := n - m
+	// When delta is odd the forward search meets the backward one, otherwise the reverse
+	forwardMeets := delta%2 != 0
+	// Diagonals that ran off the edge of the grid are skipped from then on
+	var forwardStart, forwardEnd, backwardStart, backwardEnd int
+
+	for d := 0; d < limit; d++ {
+		for k := -d + forwardStart; k <= d-forwardEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || (k != d && forward[i-1] < forward[i+1]) {
+				x = forward[i+1]
+			} else {
+				x = forward[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[i] = x
+
+			switch {
+			case x > n:
+				forwardEnd += 2
+			case y > m:
+				forwardStart += 2
+			case forwardMeets:
+				j := offset + delta - k
+				if j >= 0 && j < len(backward) && backward[j] != -1 && x >= n-backward[j] {
+					return x, y, true
+				}
+			}
+		}
+
+		for k := -d + backwardStart; k <= d-backwardEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || (k != d && backward[i-1] < backward[i+1]) {
+				x = backward[i+1]
+			} else {
+				x = backward[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[n-x-1] == b[m-y-1] {
+				x++
+				y++
+			}
+			backward[i] = x
+
+			switch {
+			case x > n:
+				backwardEnd += 2
+			case y > m:
+				backwardStart += 2
+			case !forwardMeets:
+				j := offset + delta - k
+				if j >= 0 && j < len(forward) && forward[j] != -1 && forward[j] >= n-x {
+					return forward[j], forward[j] - (j - offset), true
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+func replaceLines(a, b []string) []DiffLine {
+	script := make([]DiffLine, 0, len(a)+len(b))
+	for _, line := range a {
+		script = append(script, DiffLine{Kind: DiffDelete, Text: line})
+	}
+	for _, line := range b {
+		script = append(script, DiffLine{Kind: DiffInsert, Text: line})
+	}
+	return script
+}
+
+// UnifiedDiff groups the changes between a and b into hunks with context
+// unchanged lines around each. It returns nil when a and b are equal.
+func UnifiedDiff(a, b []string, context int) []DiffHunk {
+	script := DiffLines(a, b)
+
+	// Line numbers in a and b at which each script entry starts
+	oldAt := make([]int, len(script)+1)
+	newAt := make([]int, len(script)+1)
+	oldAt[0], newAt[0] = 1, 1
+	var changes []int
+	for i, line := range script {
+		oldAt[i+1], newAt[i+1] = oldAt[i], newAt[i]
+		if line.Kind != DiffInsert {
+			oldAt[i+1]++
+		}
+		if line.Kind != DiffDelete {
+			newAt[i+1]++
+		}
+		if line.Kind != DiffEqual {
+			changes = append(changes, i)
+		}
+	}
+
+	var hunks []DiffHunk
+	for len(changes) > 0 {
+		// Changes separated by at most twice the context share a hunk
+		last := 0
+		for last+1 < len(changes) && changes[last+1]-changes[last] <= 2*context+1 {
+			last++
+		}
+		from := max(0, changes[0]-context)
+		to := min(len(script), changes[last]+1+context)
+		hunks = append(hunks, DiffHunk{
+			OldStart: oldAt[from],
+			OldLines: oldAt[to] - oldAt[from],
+			NewStart: newAt[from],
+			NewLines: newAt[to] - newAt[from],
+			Lines:    script[from:to],
+		})
+		changes = changes[last+1:]
+	}
+	return hunks
+}
+
+// FormatUnifiedDiff renders hunks as a unified diff between files named oldName and newName.
+func FormatUnifiedDiff(oldName, newName string, hunks []DiffHunk) string {
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+	for _, hunk := range hunks {
+		sb.WriteString(hunk.Header() + "\n")
+		for _, line := range hunk.Lines {
+			sb.WriteByte(byte(line.Kind))
+			sb.WriteString(line.Text)
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
+
+// DiffableLines splits output into lines with timestamps masked, so that runs
+// printing the same thing at different times compare equal.
+func DiffableLines(output []byte) []string {
+	text := strings.TrimSuffix(string(output), "\n")
+	if text == "" {
+		return nil
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = StripTimestamps(strings.TrimSuffix(line, "\r"))
+	}
+	return lines
+}
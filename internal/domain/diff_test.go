@@ -0,0 +1,226 @@
+package domain
+
+import (
+	"math/rand/v2"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// script renders an edit script compactly, e.g. " a|-b|+c".
+func script(lines []DiffLine) string {
+	parts := make([]string, len(lines))
+	for i, line := range lines {
+		parts[i] = string(line.Kind) + line.Text
+	}
+	return strings.Join(parts, "|")
+}
+
+// applyScript returns the texts an edit script turns into each other.
+func applyScript(lines []DiffLine) (a, b []string) {
+	for _, line := range lines {
+		if line.Kind != DiffInsert {
+			a = append(a, line.Text)
+		}
+		if line.Kind != DiffDelete {
+			b = append(b, line.Text)
+		}
+	}
+	return a, b
+}
+
+func countEdits(lines []DiffLine) int {
+	edits := 0
+	for _, line := range lines {
+		if line.Kind != DiffEqual {
+			edits++
+		}
+	}
+	return edits
+}
+
+// minEdits is the length of a shortest edit script, from the longest common subsequence.
+func minEdits(a, b []string) int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	return len(a) + len(b) - 2*lcs[0][0]
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"both empty", "", "", ""},
+		{"equal", "a b c", "a b c", " a| b| c"},
+		{"insert into empty", "", "a b", "+a|+b"},
+		{"delete everything", "a b", "", "-a|-b"},
+		{"change in the middle", "a b c", "a x c", " a|-b|+x| c"},
+		{"insert at the start", "b c", "a b c", "+a| b| c"},
+		{"delete at the end", "a b c", "a b", " a| b|-c"},
+		{"moved line", "a b c d", "b c d a", "-a| b| c| d|+a"},
+		{"repeated lines", "a a b a", "a b a a", " a|-a| b|+a| a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := strings.Fields(tt.a), strings.Fields(tt.b)
+			got := DiffLines(a, b)
+			if script(got) != tt.want {
+				t.Errorf("DiffLines() = %q, want %q", script(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffLinesIsShortest(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	randomLines := func() []string {
+		lines := make([]string, rng.IntN(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + rng.IntN(4)))
+		}
+		return lines
+	}
+
+	for range 500 {
+		a, b := randomLines(), randomLines()
+		got := DiffLines(a, b)
+
+		gotA, gotB := applyScript(got)
+		if !slices.Equal(gotA, a) || !slices.Equal(gotB, b) {
+			t.Fatalf("DiffLines(%q, %q) = %q does not turn one into the other", a, b, script(got))
+		}
+		if edits, want := countEdits(got), minEdits(a, b); edits != want {
+			t.Fatalf("DiffLines(%q, %q) = %q has %d edits, want %d", a, b, script(got), edits, want)
+		}
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	numbered := func(n int) []string {
+		lines := make([]string, n)
+		for i := range lines {
+			lines[i] = string(rune('a' + i))
+		}
+		return lines
+	}
+	replace := func(lines []string, i int, text string) []string {
+		lines = slices.Clone(lines)
+		lines[i] = text
+		return lines
+	}
+	base := numbered(20)
+
+	tests := []struct {
+		name    string
+		a, b    []string
+		context int
+		want    []string // Hunk headers
+	}{
+		{"equal", base, base, 3, nil},
+		{"one change", base, replace(base, 9, "X"), 3, []string{"@@ -7,7 +7,7 @@"}},
+		{"change on the first line", base, replace(base, 0, "X"), 3, []string{"@@ -1,4 +1,4 @@"}},
+		{"nearby changes share a hunk", base, replace(replace(base, 5, "X"), 12, "Y"), 3, []string{"@@ -3,14 +3,14 @@"}},
+		{"distant changes get their own hunks", base, replace(replace(base, 2, "X"), 15, "Y"), 3, []string{"@@ -1,6 +1,6 @@", "@@ -13,7 +13,7 @@"}},
+		{"no context", base, replace(base, 9, "X"), 0, []string{"@@ -10 +10 @@"}},
+		{"insertion into empty", nil, []string{"a", "b"}, 3, []string{"@@ -0,0 +1,2 @@"}},
+		{"deletion of a line", base[:3], []string{"a", "c"}, 0, []string{"@@ -2 +1,0 @@"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, hunk := range UnifiedDiff(tt.a, tt.b, tt.context) {
+				got = append(got, hunk.Header())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("hunks %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatUnifiedDiff(t *testing.T) {
+	hunks := UnifiedDiff([]string{"a", "b", "c"}, []string{"a", "x", "c"}, 1)
+	want := "--- run 1\n+++ run 2\n@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n"
+	if got := FormatUnifiedDiff("run 1", "run 2", hunks); got != want {
+		t.Errorf("FormatUnifiedDiff() = %q, want %q", got, want)
+	}
+	if got := FormatUnifiedDiff("run 1", "run 2", nil); got != "" {
+		t.Errorf("FormatUnifiedDiff() of no hunks = %q, want nothing", got)
+	}
+}
+
+func TestDiffableLines(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{"empty", "", nil},
+		{"trailing newline", "a\nb\n", []string{"a", "b"}},
+		{"no trailing newline", "a\nb", []string{"a", "b"}},
+		{"carriage returns", "a\r\nb\r\n", []string{"a", "b"}},
+		{"blank lines are kept", "a\n\nb\n", []string{"a", "", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffableLines([]byte(tt.output)); !slices.Equal(got, tt.want) {
+				t.Errorf("DiffableLines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Runs printing the same thing at different times compare equal
+	a := DiffableLines([]byte("2026-01-02T10:00:00Z started\n"))
+	b := DiffableLines([]byte("2026-03-04T18:30:15Z started\n"))
+	if !slices.Equal(a, b) {
+		t.Errorf("timestamps were not masked: %q and %q", a, b)
+	}
+}
+
+func TestDiffLinesLongInput(t *testing.T) {
+	// 20000 lines with every 20th one changed take 2000 edits
+	a := make([]string, 20000)
+	for i := range a {
+		a[i] = "line " + strconv.Itoa(i)
+	}
+	b := slices.Clone(a)
+	for i := 0; i < len(b); i += 20 {
+		b[i] = "changed " + strconv.Itoa(i)
+	}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	got := DiffLines(a, b)
+	runtime.ReadMemStats(&after)
+
+	gotA, gotB := applyScript(got)
+	if !slices.Equal(gotA, a) || !slices.Equal(gotB, b) {
+		t.Fatal("DiffLines() does not turn one input into the other")
+	}
+	if edits := countEdits(got); edits != 2000 {
+		t.Errorf("DiffLines() has %d edits, want 2000", edits)
+	}
+	// Mostly the script itself, rather than state growing with the number of edits
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 8<<20 {
+		t.Errorf("DiffLines() allocated %d MB", allocated>>20)
+	}
+}
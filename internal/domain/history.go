@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	HistoryOutputBytes    = 4 * 1024          // End of each stream kept by a recorded run
+	DefaultHistoryKeep    = 100               // Sessions kept in the history
+	DefaultHistoryMaxSize = 100 * 1024 * 1024 // Bytes the history may take up
+)
+
+type SessionStatus string
+
+const (
+	SessionPassed    SessionStatus = "passed"
+	SessionFailed    SessionStatus = "failed"
+	SessionCancelled SessionStatus = "cancelled"
+)
+
+// Session is one recorded invocation: its configuration, timing, outcome and runs.
+type Session struct {
+	ID         int // Assigned by the history store when the session is saved
+	Config     RunConfig
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Status     SessionStatus
+	Error      string // Why execution stopped or failed, if it did
+	Summary    Summary
+	// Results are the measured runs in completion order, nil when only the session's header was loaded
+	Results []RunResult
+}
+
+// NewSession describes a finished execution of cfg. err is what execution
+// returned and cancelled tells whether the user interrupted it.
+func NewSession(cfg *RunConfig, startedAt time.Time, results []RunResult, err error, cancelled bool) Session {
+	session := Session{
+		Config:     *cfg,
+		StartedAt:  startedAt,
+		FinishedAt: time.Now(),
+		Status:     SessionPassed,
+		Summary:    Summarize(results),
+		Results:    results,
+	}
+	// The sources of a setting only matter for errors while it is being parsed
+	session.Config.Sources = nil
+	if err != nil {
+		session.Error = err.Error()
+	}
+
+	switch {
+	case cancelled:
+		session.Status = SessionCancelled
+	case err != nil || session.Summary.Executed > session.Summary.Succeeded:
+		session.Status = SessionFailed
+	}
+	return session
+}
+
+// Duration returns how long the session took.
+func (s Session) Duration() time.Duration {
+	return s.FinishedAt.Sub(s.StartedAt)
+}
+
+// CommandLine returns the session's command, or its compared commands separated by ":::".
+func (s Session) CommandLine() string {
+	commands := s.Config.Commands
+	if commands == nil {
+		commands = [][]string{s.Config.Command}
+	}
+
+	parts := make([]string, 0, len(commands))
+	for _, command := range commands {
+		parts = append(parts, strings.Join(command, " "))
+	}
+	return strings.Join(parts, " ::: ")
+}
+
+// ForHistory returns a copy of the result that keeps only the end of its output,
+// read from the spool file when output went to disk, or no output at all unless
+// keepOutput is set, so recorded sessions stay small.
+func (r RunResult) ForHistory(keepOutput bool) RunResult {
+	if keepOutput {
+		r.Stdout = r.StdoutTail(HistoryOutputBytes)
+		r.Stderr = r.StderrTail(HistoryOutputBytes)
+	} else {
+		r.Stdout, r.Stderr = nil, nil
+	}
+	r.StdoutPath = ""
+	r.StderrPath = ""
+	return r
+}
+
+// SessionFilter selects recorded sessions. Zero fields match every session.
+type SessionFilter struct {
+	Command string // Substring of the command line
+	Status  SessionStatus
+	Since   time.Time // Sessions started at or after this time
+}
+
+func (f SessionFilter) Match(s Session) bool {
+	if f.Command != "" && !strings.Contains(s.CommandLine(), f.Command) {
+		return false
+	}
+	if f.Status != "" && s.Status != f.Status {
+		return false
+	}
+	return f.Since.IsZero() || !s.StartedAt.Before(f.Since)
+}
+
+// FilterSessions returns the sessions matched by f, in their original order.
+func FilterSessions(sessions []Session, f SessionFilter) []Session {
+	var matched []Session
+	for _, s := range sessions {
+		if f.Match(s) {
+			matched = append(matched, s)
+		}
+	}
+	return matched
+}
+
+// SessionsToPrune returns the sessions beyond the keep most recent ones, together with
+// those started before cutoff. keep 0 and a zero cutoff disable the respective rule.
+func SessionsToPrune(sessions []Session, keep int, cutoff time.Time) []Session {
+	newestFirst := slices.Clone(sessions)
+	slices.SortStableFunc(newestFirst, func(a, b Session) int {
+		return b.StartedAt.Compare(a.StartedAt)
+	})
+
+	var pruned []Session
+	for i, s := range newestFirst {
+		if (keep > 0 && i >= keep) || (!cutoff.IsZero() && s.StartedAt.Before(cutoff)) {
+			pruned = append(pruned, s)
+		}
+	}
+	return pruned
+}
@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunResultForHistory(t *testing.T) {
+	long := strings.Repeat("x", HistoryOutputBytes) + "end"
+	result := RunResult{Stdout: []byte(long), Stderr: []byte("err"), StdoutPath: "spool/out"}
+
+	tests := []struct {
+		name       string
+		keepOutput bool
+		stdout     string
+		stderr     string
+	}{
+		{"output dropped", false, "", ""},
+		{"end of the output kept", true, long[len(long)-HistoryOutputBytes:], "err"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := result
+			// Spooled output is read from the file, which the in-memory output stands in for here
+			r.StdoutPath = ""
+			got := r.ForHistory(tt.keepOutput)
+			if string(got.Stdout) != tt.stdout || string(got.Stderr) != tt.stderr {
+				t.Errorf("kept %d bytes of stdout and %q of stderr, want %d and %q",
+					len(got.Stdout), got.Stderr, len(tt.stdout), tt.stderr)
+			}
+		})
+	}
+
+	if got := result.ForHistory(false); got.StdoutPath != "" || got.StderrPath != "" {
+		t.Errorf("spool paths %q and %q were recorded", got.StdoutPath, got.StderrPath)
+	}
+}
+
+func TestSessionsToPrune(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	// Listed newest first, as the history returns them
+	var sessions []Session
+	for id := 5; id >= 1; id-- {
+		sessions = append(sessions, Session{ID: id, StartedAt: now.Add(-time.Duration(5-id) * 24 * time.Hour)})
+	}
+
+	tests := []struct {
+		name   string
+		keep   int
+		cutoff time.Time
+		want   []int
+	}{
+		{"nothing", 0, time.Time{}, nil},
+		{"keep", 2, time.Time{}, []int{3, 2, 1}},
+		{"keep more than exist", 10, time.Time{}, nil},
+		{"older than", 0, now.Add(-48 * time.Hour), []int{2, 1}},
+		{"either rule", 4, now.Add(-48 * time.Hour), []int{2, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for _, s := range SessionsToPrune(sessions, tt.keep, tt.cutoff) {
+				got = append(got, s.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("SessionsToPrune() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
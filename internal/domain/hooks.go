@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"fmt"
+	"iter"
+	"time"
+)
+
+// HookPhase names when a hook command runs.
+type HookPhase string
+
+const (
+	HookSetup      HookPhase = "setup"
+	HookTeardown   HookPhase = "teardown"
+	HookBeforeEach HookPhase = "before-each"
+	HookAfterEach  HookPhase = "after-each"
+)
+
+// Hook returns the command configured for phase, nil when there is none.
+func (c *RunConfig) Hook(phase HookPhase) []string {
+	var hook string
+	switch phase {
+	case HookSetup:
+		hook = c.Setup
+	case HookTeardown:
+		hook = c.Teardown
+	case HookBeforeEach:
+		hook = c.BeforeEach
+	case HookAfterEach:
+		hook = c.AfterEach
+	}
+	if hook == "" {
+		return nil
+	}
+	return []string{hook}
+}
+
+// HookCommands yields every configured hook; phases double as option names.
+func (c *RunConfig) HookCommands() iter.Seq2[HookPhase, []string] {
+	return func(yield func(HookPhase, []string) bool) {
+		for _, phase := range []HookPhase{HookSetup, HookBeforeEach, HookAfterEach, HookTeardown} {
+			if hook := c.Hook(phase); hook != nil && !yield(phase, hook) {
+				return
+			}
+		}
+	}
+}
+
+// HookResult is the outcome of one hook command. Hooks succeed on exit code 0
+// regardless of the success criteria configured for the measured command.
+type HookResult struct {
+	Phase    HookPhase
+	Command  []string
+	ExitCode int
+	Duration time.Duration
+	Success  bool
+	Failure  FailureKind
+	Reason   string // Failure description
+	Stderr   []byte
+	Error    error
+}
+
+func NewHookResult(phase HookPhase, result RunResult) HookResult {
+	return HookResult{
+		Phase:    phase,
+		Command:  result.Command,
+		ExitCode: result.ExitCode,
+		Duration: result.Duration,
+		Success:  result.Success,
+		Failure:  result.Failure,
+		Reason:   result.FailureDescription(),
+		Stderr:   result.StderrBytes(),
+		Error:    result.Error,
+	}
+}
+
+// HookFailed reports whether any of the run's hooks failed.
+func (r RunResult) HookFailed() bool {
+	for _, hook := range r.Hooks {
+		if !hook.Success {
+			return true
+		}
+	}
+	return false
+}
+
+// HookFailedResult describes an iteration whose command was not run because its before-each hook failed.
+func HookFailedResult(id int, command []string, hook HookResult) RunResult {
+	now := time.Now()
+	return RunResult{
+		ID:         id,
+		Command:    command,
+		ExitCode:   -1,
+		StartedAt:  now,
+		FinishedAt: now,
+		Status:     StatusFailed,
+		Failure:    FailureHook,
+		Reason:     fmt.Sprintf("%s hook failed: %s", hook.Phase, hook.Reason),
+		Hooks:      []HookResult{hook},
+	}
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -14,27 +15,209 @@ const (
 )
 
 const (
-	FormatTUI  OutputFormat = "tui"
-	FormatJSON OutputFormat = "json"
-	FormatRaw  OutputFormat = "raw"
+	FormatTUI    OutputFormat = "tui"
+	FormatJSON   OutputFormat = "json"
+	FormatNDJSON OutputFormat = "ndjson"
+	FormatJUnit  OutputFormat = "junit"
+	FormatRaw    OutputFormat = "raw"
+)
+
+type RetentionMode string
+
+const (
+	RetainHead     RetentionMode = "head"
+	RetainHeadTail RetentionMode = "head-tail"
+)
+
+// DefaultMaxOutput is the per-stream capture limit when none is configured.
+const DefaultMaxOutput = 10 * 1024 * 1024
+
+type UntilMode string
+
+const (
+	UntilNone    UntilMode = ""
+	UntilFail    UntilMode = "fail"
+	UntilSuccess UntilMode = "success"
+	UntilStreak  UntilMode = "streak"
+)
+
+type RunStatus string
+
+const (
+	StatusSuccess RunStatus = "success"
+	StatusFailed  RunStatus = "failed"
+	StatusSkipped RunStatus = "skipped"
+	StatusTimeout RunStatus = "timeout"
+)
+
+// FailureKind classifies why a run did not succeed.
+type FailureKind string
+
+const (
+	FailureNone        FailureKind = ""
+	FailureExitNonZero FailureKind = "exit-nonzero"
+	FailureSignaled    FailureKind = "signaled"
+	FailureTimeout     FailureKind = "timeout"
+	FailureCancelled   FailureKind = "cancelled"
+	FailureStartError  FailureKind = "start-error"
+	FailureAssertion   FailureKind = "assertion"
+	FailureHook        FailureKind = "hook" // A before-each hook failed, so the command never ran
 )
 
 type RunConfig struct {
-	Command   []string
-	Times     int
-	Verbosity VerbosityLevel
-	Format    OutputFormat
-	Timeout   time.Duration
+	Command []string
+	// Commands lists every command of a comparison session, Command being the first; nil otherwise
+	Commands [][]string
+	// Interleave alternates between compared commands instead of running each one's iterations in turn
+	Interleave bool
+	// Templates expands {{.ID}}-style templates in command and hook arguments
+	Templates bool
+	// Times is the number of iterations of each command; in until modes it is an optional cap where 0 means unlimited
+	Times           int
+	Parallel        int
+	Verbosity       VerbosityLevel
+	Format          OutputFormat
+	Timeout         time.Duration
+	KillGrace       time.Duration // Time between SIGTERM and SIGKILL when stopping a run, 0 kills immediately
+	FailFast        bool
+	MaxFailures     int
+	MaxFailureRate  float64 // Percentage of Times, 0 disables
+	Until           UntilMode
+	Streak          int           // Consecutive successes required by UntilStreak
+	TimeBudget      time.Duration // Keep starting runs until this much time has passed, 0 disables
+	KillAtDeadline  bool          // Kill in-flight runs when TimeBudget expires instead of letting them finish
+	Interval        time.Duration // Minimum time between consecutive run starts
+	Jitter          time.Duration // Random extra delay in [0, Jitter) added to each interval
+	Rate            float64       // Maximum run starts per second, 0 disables
+	SuccessCodes    []int         // Exit codes counted as success, defaults to 0
+	ExpectStdout    string        // Regexp stdout must match for a run to succeed
+	RejectOutput    string        // Regexp that fails a run when stdout or stderr matches
+	MaxOutput       int64         // Bytes retained per stream, 0 uses DefaultMaxOutput
+	OutputRetention RetentionMode // Which part of oversized output to keep
+	SpoolDir        string        // Write complete output to per-run files here instead of memory
+	ArtifactsDir    string        // Persist every run's output and metadata under run-NNNN directories
+	// ArtifactsFailedOnly keeps artifacts of failed runs only
+	ArtifactsFailedOnly bool
+	Warmup              int        // Iterations run before the measured ones and excluded from results
+	IncludeWarmup       bool       // Report warmup iterations separately instead of dropping them
+	Setup               string     // Shell command run once before the first run
+	Teardown            string     // Shell command run once after the last run, even when interrupted
+	BeforeEach          string     // Shell command run before every run, outside its measured duration
+	AfterEach           string     // Shell command run after every run, outside its measured duration
+	Parser              ParserKind // Format to parse per-test results from, empty disables parsing
+	ParseFile           string     // Report written by each run and parsed instead of stdout, may contain templates
+	SaveBaseline        string     // File to store the measured durations and success rate in
+	Baseline            string     // Baseline file to test the measured durations against
+	// Threshold is the slowdown from the baseline, in percent, tolerated before a regression is reported
+	Threshold float64
+	// History records the session in the run history, which is opt-in since it keeps command lines and output
+	History        bool
+	HistoryOutput  bool  // Record the output of every run rather than only of failed ones
+	HistoryKeep    int   // Sessions kept in the history, oldest deleted first, 0 keeps all
+	HistoryMaxSize int64 // Bytes the history may take up, oldest sessions deleted first, 0 disables
+	// Sources records which options were set by configuration files, for error messages
+	Sources ConfigSources
+}
+
+// OpenEnded reports whether the number of iterations is decided while running.
+func (c *RunConfig) OpenEnded() bool {
+	return c.Until != UntilNone || c.TimeBudget > 0
+}
+
+// ResourceUsage is what the operating system accounted to a finished run.
+type ResourceUsage struct {
+	UserTime               time.Duration
+	SystemTime             time.Duration
+	MaxRSS                 int64 // Peak resident set size in bytes
+	VoluntaryCtxSwitches   int64
+	InvoluntaryCtxSwitches int64
 }
 
 type RunResult struct {
 	ID         int
+	Command    []string // Command after template expansion
 	ExitCode   int
 	Stdout     []byte
 	Stderr     []byte
-	Duration   time.Duration
-	StartedAt  time.Time
-	FinishedAt time.Time
-	Success    bool
-	Error      error
+	StdoutPath string // Spool file holding stdout, in which case Stdout is empty
+	StderrPath string
+	// ArtifactDir holds the run's persisted output, metadata and archived scratch directory
+	ArtifactDir string
+	Duration    time.Duration
+	StartedAt   time.Time
+	FinishedAt  time.Time
+	Usage       ResourceUsage
+	Success     bool
+	Status      RunStatus
+	Failure     FailureKind
+	Signal      string // Terminating signal name such as SIGSEGV, set for FailureSignaled
+	CoreDumped  bool
+	Reason      string // Why custom success criteria accepted or rejected the run
+	Error       error
+	Hooks       []HookResult // Before-each and after-each hooks, in the order they ran
+	Warmup      bool         // A warmup iteration, whose ID counts warmups rather than runs
+	// CommandIndex is the 1-based position of the command in a comparison, 0 otherwise
+	CommandIndex int
+	Tests        []TestOutcome // Test cases parsed from the run's output
+	Signature    string        // Normalized failure signature, empty unless the run failed
+}
+
+// FailureDescription explains in a few words why the run failed.
+func (r RunResult) FailureDescription() string {
+	switch r.Failure {
+	case FailureExitNonZero:
+		if r.Reason != "" {
+			return r.Reason
+		}
+		return fmt.Sprintf("exit code %d", r.ExitCode)
+	case FailureSignaled:
+		if r.CoreDumped {
+			return fmt.Sprintf("killed by %s (core dumped)", r.Signal)
+		}
+		return fmt.Sprintf("killed by %s", r.Signal)
+	case FailureTimeout:
+		return "timed out"
+	case FailureCancelled:
+		return "cancelled"
+	case FailureStartError:
+		if r.Error != nil {
+			return fmt.Sprintf("failed to start: %v", r.Error)
+		}
+		return "failed to start"
+	case FailureAssertion:
+		if r.Reason != "" {
+			return r.Reason
+		}
+		return "output assertion failed"
+	case FailureHook:
+		if r.Reason != "" {
+			return r.Reason
+		}
+		return "hook failed"
+	default:
+		return ""
+	}
+}
+
+// StartFailedResult describes an iteration that could not be prepared or started.
+func StartFailedResult(id int, command []string, err error) RunResult {
+	now := time.Now()
+	return RunResult{
+		ID:         id,
+		Command:    command,
+		ExitCode:   -1,
+		StartedAt:  now,
+		FinishedAt: now,
+		Status:     StatusFailed,
+		Failure:    FailureStartError,
+		Error:      err,
+	}
+}
+
+// SkippedResult describes an iteration that was never run because execution stopped early.
+func SkippedResult(id int) RunResult {
+	return RunResult{
+		ID:     id,
+		Status: StatusSkipped,
+	}
 }
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// StdoutBytes returns captured stdout, reading the spool file when output went to disk.
+func (r RunResult) StdoutBytes() []byte {
+	return readOutput(r.Stdout, r.StdoutPath)
+}
+
+// OpenStdout streams captured stdout, from the spool file when output went to disk.
+func (r RunResult) OpenStdout() (io.ReadCloser, error) {
+	if r.StdoutPath == "" {
+		return io.NopCloser(bytes.NewReader(r.Stdout)), nil
+	}
+	return os.Open(r.StdoutPath)
+}
+
+// StderrBytes returns captured stderr, reading the spool file when output went to disk.
+func (r RunResult) StderrBytes() []byte {
+	return readOutput(r.Stderr, r.StderrPath)
+}
+
+// StderrTail returns at most the last n bytes of stderr without loading a whole spool file.
+func (r RunResult) StderrTail(n int) []byte {
+	return tailOutput(r.Stderr, r.StderrPath, n)
+}
+
+// StdoutTail returns at most the last n bytes of stdout without loading a whole spool file.
+func (r RunResult) StdoutTail(n int) []byte {
+	return tailOutput(r.Stdout, r.StdoutPath, n)
+}
+
+func tailOutput(data []byte, path string, n int) []byte {
+	if path == "" {
+		if len(data) > n {
+			return data[len(data)-n:]
+		}
+		return data
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	if info, err := f.Stat(); err == nil && info.Size() > int64(n) {
+		if _, err := f.Seek(-int64(n), io.SeekEnd); err != nil {
+			return nil
+		}
+	}
+	tail, _ := io.ReadAll(f)
+	return tail
+}
+
+func readOutput(data []byte, path string) []byte {
+	if path == "" {
+		return data
+	}
+	// A missing spool file reads as empty output rather than failing the caller
+	spooled, _ := os.ReadFile(path)
+	return spooled
+}
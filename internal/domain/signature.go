@@ -0,0 +1,183 @@
+package domain
+
+import (
+	"cmp"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	signatureTailBytes = 4 * 1024 // Output read from the end of a stream
+	signatureLines     = 5        // Trailing non-empty lines that make up a signature
+)
+
+type outputMask struct {
+	pattern *regexp.Regexp
+	mask    string
+}
+
+// timestampMasks match dates and times, most specific first.
+var timestampMasks = []outputMask{
+	{regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`), "<time>"},
+	{regexp.MustCompile(`\d{4}[/-]\d{2}[/-]\d{2}`), "<date>"},
+	{regexp.MustCompile(`\d{1,2}:\d{2}:\d{2}(\.\d+)?`), "<time>"},
+}
+
+// Volatile parts of output that differ between otherwise identical failures,
+// applied in order so that timestamps are replaced before their digits are.
+var signatureMasks = slices.Concat(timestampMasks, []outputMask{
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), "<uuid>"},
+	{regexp.MustCompile(`(?i)\b0x[0-9a-f]+\b`), "<addr>"},
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8,}\b`), "<hex>"},
+	{regexp.MustCompile(`\d+(\.\d+)?`), "<n>"},
+	{regexp.MustCompile(`\s+`), " "},
+})
+
+// StripTimestamps masks the dates and times in line.
+func StripTimestamps(line string) string {
+	for _, m := range timestampMasks {
+		line = m.pattern.ReplaceAllString(line, m.mask)
+	}
+	return line
+}
+
+// NormalizeOutputLine masks timestamps, addresses, identifiers and numbers so that
+// lines from different runs of the same failure compare equal.
+func NormalizeOutputLine(line string) string {
+	for _, m := range signatureMasks {
+		line = m.pattern.ReplaceAllString(line, m.mask)
+	}
+	return strings.TrimSpace(line)
+}
+
+// outputTail returns the last non-empty lines of stderr, or of stdout when the
+// run wrote nothing to stderr.
+func (r RunResult) outputTail() []string {
+	lines := tailLines(r.StderrTail(signatureTailBytes))
+	if len(lines) == 0 {
+		lines = tailLines(r.StdoutTail(signatureTailBytes))
+	}
+	return lines
+}
+
+func tailLines(data []byte) []string {
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines[max(0, len(lines)-signatureLines):]
+}
+
+// FailureSignature identifies the failure mode of a failed run: how it failed,
+// which parsed tests failed, and the normalized tail of its output. It is empty
+// for runs that succeeded or were skipped.
+func FailureSignature(r RunResult) string {
+	if r.Success || r.Status == StatusSkipped {
+		return ""
+	}
+
+	header := string(r.Failure)
+	switch r.Failure {
+	case FailureExitNonZero:
+		header = fmt.Sprintf("%s %d", r.Failure, r.ExitCode)
+	case FailureSignaled:
+		header = fmt.Sprintf("%s %s", r.Failure, r.Signal)
+	case FailureAssertion, FailureHook:
+		header = fmt.Sprintf("%s %s", r.Failure, NormalizeOutputLine(r.Reason))
+	}
+
+	parts := []string{header}
+	if failed := r.FailedTests(); len(failed) > 0 {
+		names := make([]string, 0, len(failed))
+		for _, test := range failed {
+			names = append(names, test.Name)
+		}
+		slices.Sort(names)
+		parts = append(parts, "failed tests: "+strings.Join(names, ", "))
+	}
+	for _, line := range r.outputTail() {
+		parts = append(parts, NormalizeOutputLine(line))
+	}
+	return strings.Join(parts, "\n")
+}
+
+// SignatureID shortens a failure signature to a stable identifier.
+func SignatureID(signature string) string {
+	sum := sha1.Sum([]byte(signature))
+	return hex.EncodeToString(sum[:4])
+}
+
+// FailureCluster groups failed runs that share a failure signature.
+type FailureCluster struct {
+	ID        string // Short hash of the signature
+	Signature string
+	Failure   FailureKind
+	Runs      []int    // IDs of the runs in the cluster, in the order they were added
+	Example   int      // ID of the first run added to the cluster
+	Output    []string // Unnormalized output tail of the example run
+}
+
+// Count returns the number of runs in the cluster.
+func (c FailureCluster) Count() int {
+	return len(c.Runs)
+}
+
+// FailureClusterer groups failed runs by failure signature.
+type FailureClusterer struct {
+	clusters map[string]*FailureCluster
+	order    []string // Signatures in the order they were first seen
+}
+
+func NewFailureClusterer() *FailureClusterer {
+	return &FailureClusterer{clusters: make(map[string]*FailureCluster)}
+}
+
+// Add files result under its signature; runs without one are ignored.
+func (c *FailureClusterer) Add(result RunResult) {
+	if result.Signature == "" {
+		return
+	}
+
+	cluster, ok := c.clusters[result.Signature]
+	if !ok {
+		cluster = &FailureCluster{
+			ID:        SignatureID(result.Signature),
+			Signature: result.Signature,
+			Failure:   result.Failure,
+			Example:   result.ID,
+			Output:    result.outputTail(),
+		}
+		c.clusters[result.Signature] = cluster
+		c.order = append(c.order, result.Signature)
+	}
+	cluster.Runs = append(cluster.Runs, result.ID)
+}
+
+// Clusters returns every cluster, largest first.
+func (c *FailureClusterer) Clusters() []FailureCluster {
+	clusters := make([]FailureCluster, 0, len(c.order))
+	for _, signature := range c.order {
+		cluster := *c.clusters[signature]
+		cluster.Runs = slices.Clone(cluster.Runs)
+		clusters = append(clusters, cluster)
+	}
+	slices.SortStableFunc(clusters, func(a, b FailureCluster) int {
+		return cmp.Compare(b.Count(), a.Count())
+	})
+	return clusters
+}
+
+// ClusterFailures groups the failed runs of a complete set of results.
+func ClusterFailures(results []RunResult) []FailureCluster {
+	c := NewFailureClusterer()
+	for _, result := range results {
+		c.Add(result)
+	}
+	return c.Clusters()
+}
@@ -0,0 +1,170 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeOutputLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"plain message", "plain message"},
+		{"2026-10-17T01:04:31+00:00 connection refused", "<time> connection refused"},
+		{"2026-10-17 01:04:31.123Z started", "<time> started"},
+		{"[2026/10/17] backup", "[<date>] backup"},
+		{"took 12:03:04.5 total", "took <time> total"},
+		{"request 123e4567-e89b-12d3-a456-426614174000 failed", "request <uuid> failed"},
+		{"nil pointer at 0x7f3a2b", "nil pointer at <addr>"},
+		{"commit deadbeefcafe broke it", "commit <hex> broke it"},
+		{"index out of range [5] with length 3", "index out of range [<n>] with length <n>"},
+		{"dial 10.0.0.4:5432 timed out after 1.5s", "dial <n>.<n>:<n> timed out after <n>s"},
+		{"  spaced \t out  ", "spaced out"},
+		{"short hex abc123 is a word", "short hex abc<n> is a word"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeOutputLine(tt.line); got != tt.want {
+			t.Errorf("NormalizeOutputLine(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestStripTimestamps(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"2026-10-17T01:04:31Z run 3 of 10", "<time> run 3 of 10"},
+		{"at 09:15:00 on 2026-01-02", "at <time> on <date>"},
+		{"no time here: 42", "no time here: 42"},
+	}
+
+	for _, tt := range tests {
+		if got := StripTimestamps(tt.line); got != tt.want {
+			t.Errorf("StripTimestamps(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestFailureSignature(t *testing.T) {
+	failed := func(exitCode int, stdout, stderr string) RunResult {
+		return RunResult{
+			Status:   StatusFailed,
+			Failure:  FailureExitNonZero,
+			ExitCode: exitCode,
+			Stdout:   []byte(stdout),
+			Stderr:   []byte(stderr),
+		}
+	}
+	withTests := func(r RunResult, tests ...TestOutcome) RunResult {
+		r.Tests = tests
+		return r
+	}
+
+	tests := []struct {
+		name   string
+		result RunResult
+		want   string
+	}{
+		{
+			name:   "succeeded",
+			result: RunResult{Status: StatusSuccess, Success: true, Stderr: []byte("warning")},
+			want:   "",
+		},
+		{
+			name:   "skipped",
+			result: RunResult{Status: StatusSkipped},
+			want:   "",
+		},
+		{
+			name:   "stderr tail is preferred",
+			result: failed(1, "progress\n", "\nstarting\nerror at 0x1f: code 7\n\n"),
+			want:   "exit-nonzero 1\nstarting\nerror at <addr>: code <n>",
+		},
+		{
+			name:   "stdout when stderr is empty",
+			result: failed(2, "FAIL 12 tests\n", ""),
+			want:   "exit-nonzero 2\nFAIL <n> tests",
+		},
+		{
+			name:   "only the last lines",
+			result: failed(1, "", "1\n2\n3\na\nb\nc\nd\ne\n"),
+			want:   "exit-nonzero 1\na\nb\nc\nd\ne",
+		},
+		{
+			name:   "no output",
+			result: RunResult{Status: StatusTimeout, Failure: FailureTimeout},
+			want:   "timeout",
+		},
+		{
+			name:   "signal",
+			result: RunResult{Status: StatusFailed, Failure: FailureSignaled, Signal: "SIGSEGV"},
+			want:   "signaled SIGSEGV",
+		},
+		{
+			name:   "assertion reason is normalized",
+			result: RunResult{Status: StatusFailed, Failure: FailureAssertion, Reason: "stdout matched reject pattern at line 12"},
+			want:   "assertion stdout matched reject pattern at line <n>",
+		},
+		{
+			name: "failed tests are sorted",
+			result: withTests(failed(1, "", "FAIL\n"),
+				TestOutcome{Name: "TestB", Status: TestFail},
+				TestOutcome{Name: "TestC", Status: TestPass},
+				TestOutcome{Name: "TestA", Status: TestFail}),
+			want: "exit-nonzero 1\nfailed tests: TestA, TestB\nFAIL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FailureSignature(tt.result); got != tt.want {
+				t.Errorf("FailureSignature() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClusterFailures(t *testing.T) {
+	run := func(id int, stderr string) RunResult {
+		r := RunResult{ID: id, Status: StatusFailed, Failure: FailureExitNonZero, ExitCode: 1, Stderr: []byte(stderr)}
+		r.Signature = FailureSignature(r)
+		return r
+	}
+	passed := RunResult{ID: 3, Status: StatusSuccess, Success: true}
+
+	results := []RunResult{
+		run(1, "2026-10-17T01:00:00Z connection refused (conn 0x7f1)\n"),
+		run(2, "panic: index out of range [2]\n"),
+		passed,
+		run(4, "2026-10-17T02:30:00Z connection refused (conn 0x7f4)\n"),
+		run(5, "panic: index out of range [5]\n"),
+		run(6, "2026-10-17T03:00:00Z connection refused (conn 0x7f6)\n"),
+	}
+
+	clusters := ClusterFailures(results)
+	if len(clusters) != 2 {
+		t.Fatalf("got %d clusters, want 2: %+v", len(clusters), clusters)
+	}
+
+	refused, panics := clusters[0], clusters[1]
+	if !slices.Equal(refused.Runs, []int{1, 4, 6}) || refused.Example != 1 || refused.Failure != FailureExitNonZero {
+		t.Errorf("largest cluster %+v, want runs 1, 4 and 6 with run 1 as the example", refused)
+	}
+	if !slices.Equal(panics.Runs, []int{2, 5}) || panics.Example != 2 {
+		t.Errorf("second cluster %+v, want runs 2 and 5 with run 2 as the example", panics)
+	}
+	if refused.ID != SignatureID(refused.Signature) || len(refused.ID) != 8 {
+		t.Errorf("cluster ID %q does not identify its signature", refused.ID)
+	}
+	// The example keeps its output as printed
+	if want := []string{"2026-10-17T01:00:00Z connection refused (conn 0x7f1)"}; !slices.Equal(refused.Output, want) {
+		t.Errorf("example output %q, want %q", refused.Output, want)
+	}
+	if !strings.Contains(refused.Signature, "<time> connection refused (conn <addr>)") {
+		t.Errorf("signature %q is not normalized", refused.Signature)
+	}
+}
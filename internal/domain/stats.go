@@ -0,0 +1,283 @@
+package domain
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+type DurationStats struct {
+	Count  int
+	Mean   time.Duration
+	Min    time.Duration
+	Max    time.Duration
+	StdDev time.Duration
+	P50    time.Duration
+	P90    time.Duration
+	P95    time.Duration
+	P99    time.Duration
+}
+
+type ResourceStats struct {
+	UserTime   DurationStats
+	SystemTime DurationStats
+	MaxRSSMean int64 // Bytes
+	MaxRSSPeak int64 // Bytes
+}
+
+type Summary struct {
+	Total       int // Every reported run, including skipped ones
+	Executed    int
+	Succeeded   int
+	Failed      int // Executed runs that neither succeeded nor timed out
+	TimedOut    int
+	Skipped     int
+	HookFailed  int           // Runs with a failed before-each or after-each hook
+	SuccessRate float64       // Percentage of executed runs that succeeded
+	WallTime    time.Duration // From the first start to the last finish
+	Throughput  float64       // Runs of the command per second of wall time, excluding those stopped by a before-each hook
+	Durations   DurationStats
+	Resources   ResourceStats
+}
+
+// StatsCollector accumulates run results into a Summary without retaining their output.
+type StatsCollector struct {
+	summary   Summary
+	durations []time.Duration
+	userTimes []time.Duration
+	sysTimes  []time.Duration
+	rssTotal  int64
+	rssPeak   int64
+	rssRuns   int // Runs with resource usage, which the command must have started for
+	firstAt   time.Time
+	lastAt    time.Time
+}
+
+func NewStatsCollector() *StatsCollector {
+	return &StatsCollector{}
+}
+
+func (c *StatsCollector) Add(result RunResult) {
+	c.summary.Total++
+	if result.HookFailed() {
+		c.summary.HookFailed++
+	}
+
+	switch result.Status {
+	case StatusSkipped:
+		c.summary.Skipped++
+		return
+	case StatusSuccess:
+		c.summary.Succeeded++
+	case StatusTimeout:
+		c.summary.TimedOut++
+	default:
+		c.summary.Failed++
+	}
+
+	c.summary.Executed++
+	// The command never ran, so there is nothing to measure
+	if result.Failure == FailureHook {
+		return
+	}
+	c.durations = append(c.durations, result.Duration)
+	if result.Failure != FailureStartError {
+		c.userTimes = append(c.userTimes, result.Usage.UserTime)
+		c.sysTimes = append(c.sysTimes, result.Usage.SystemTime)
+		c.rssTotal += result.Usage.MaxRSS
+		c.rssPeak = max(c.rssPeak, result.Usage.MaxRSS)
+		c.rssRuns++
+	}
+
+	if c.firstAt.IsZero() || result.StartedAt.Before(c.firstAt) {
+		c.firstAt = result.StartedAt
+	}
+	if result.FinishedAt.After(c.lastAt) {
+		c.lastAt = result.FinishedAt
+	}
+}
+
+func (c *StatsCollector) Summary() Summary {
+	s := c.summary
+	s.Durations = ComputeDurationStats(c.durations)
+	s.Resources = ResourceStats{
+		UserTime:   ComputeDurationStats(c.userTimes),
+		SystemTime: ComputeDurationStats(c.sysTimes),
+		MaxRSSPeak: c.rssPeak,
+	}
+
+	if s.Executed > 0 {
+		s.SuccessRate = float64(s.Succeeded) * 100 / float64(s.Executed)
+	}
+	if c.rssRuns > 0 {
+		s.Resources.MaxRSSMean = c.rssTotal / int64(c.rssRuns)
+	}
+
+	if !c.firstAt.IsZero() && c.lastAt.After(c.firstAt) {
+		s.WallTime = c.lastAt.Sub(c.firstAt)
+		s.Throughput = float64(len(c.durations)) / s.WallTime.Seconds()
+	}
+
+	return s
+}
+
+// Durations returns the measured durations of executed runs, in the order they were added.
+func (c *StatsCollector) Durations() []time.Duration {
+	return slices.Clone(c.durations)
+}
+
+// Summarize builds a Summary from a complete set of results.
+func Summarize(results []RunResult) Summary {
+	c := NewStatsCollector()
+	for _, result := range results {
+		c.Add(result)
+	}
+	return c.Summary()
+}
+
+func ComputeDurationStats(durations []time.Duration) DurationStats {
+	if len(durations) == 0 {
+		return DurationStats{}
+	}
+
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+
+	var sum float64
+	for _, d := range sorted {
+		sum += float64(d)
+	}
+	mean := sum / float64(len(sorted))
+
+	var variance float64
+	if len(sorted) > 1 {
+		for _, d := range sorted {
+			diff := float64(d) - mean
+			variance += diff * diff
+		}
+		variance /= float64(len(sorted) - 1)
+	}
+
+	return DurationStats{
+		Count:  len(sorted),
+		Mean:   time.Duration(mean),
+		Min:    sorted[0],
+		Max:    sorted[len(sorted)-1],
+		StdDev: time.Duration(math.Sqrt(variance)),
+		P50:    Percentile(sorted, 50),
+		P90:    Percentile(sorted, 90),
+		P95:    Percentile(sorted, 95),
+		P99:    Percentile(sorted, 99),
+	}
+}
+
+// Percentile interpolates linearly between the closest ranks of an ascending slice.
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower == upper {
+		return sorted[lower]
+	}
+
+	weight := rank - float64(lower)
+	return sorted[lower] + time.Duration(weight*float64(sorted[upper]-sorted[lower]))
+}
+
+// StudentTCDF returns P(T <= t) for Student's t-distribution with df degrees of freedom.
+func StudentTCDF(t, df float64) float64 {
+	tail := 0.5 * regularizedIncompleteBeta(df/(df+t*t), df/2, 0.5)
+	if t > 0 {
+		return 1 - tail
+	}
+	return tail
+}
+
+// StudentTQuantile inverts StudentTCDF by bisection.
+func StudentTQuantile(p, df float64) float64 {
+	switch {
+	case p <= 0:
+		return math.Inf(-1)
+	case p >= 1:
+		return math.Inf(1)
+	case p < 0.5:
+		return -StudentTQuantile(1-p, df)
+	}
+
+	lo, hi := 0.0, 1.0
+	for StudentTCDF(hi, df) < p {
+		lo, hi = hi, hi*2
+	}
+	for range 100 {
+		mid := (lo + hi) / 2
+		if StudentTCDF(mid, df) < p {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// regularizedIncompleteBeta computes I_x(a, b) with a continued fraction,
+// using the symmetry I_x(a, b) = 1 - I_{1-x}(b, a) where that converges faster.
+func regularizedIncompleteBeta(x, a, b float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+
+	lgab, _ := math.Lgamma(a + b)
+	lga, _ := math.Lgamma(a)
+	lgb, _ := math.Lgamma(b)
+	front := math.Exp(lgab - lga - lgb + a*math.Log(x) + b*math.Log1p(-x))
+
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(x, a, b) / a
+	}
+	return 1 - front*betaContinuedFraction(1-x, b, a)/b
+}
+
+// betaContinuedFraction evaluates the incomplete beta continued fraction with Lentz's method.
+func betaContinuedFraction(x, a, b float64) float64 {
+	const (
+		maxIterations = 300
+		epsilon       = 1e-14
+		tiny          = 1e-300
+	)
+	clamp := func(v float64) float64 {
+		if math.Abs(v) < tiny {
+			return tiny
+		}
+		return v
+	}
+
+	c := 1.0
+	d := 1 / clamp(1-(a+b)*x/(a+1))
+	h := d
+	for m := 1; m <= maxIterations; m++ {
+		fm := float64(m)
+
+		num := fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm))
+		d = 1 / clamp(1+num*d)
+		c = clamp(1 + num/c)
+		h *= d * c
+
+		num = -(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1))
+		d = 1 / clamp(1+num*d)
+		c = clamp(1 + num/c)
+		delta := d * c
+		h *= delta
+
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return h
+}
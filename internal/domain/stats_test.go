@@ -0,0 +1,204 @@
+package domain
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func ms(values ...float64) []time.Duration {
+	durations := make([]time.Duration, len(values))
+	for i, v := range values {
+		durations[i] = time.Duration(v * float64(time.Millisecond))
+	}
+	return durations
+}
+
+func TestComputeDurationStats(t *testing.T) {
+	tests := []struct {
+		name      string
+		durations []time.Duration
+		want      DurationStats
+	}{
+		{
+			name: "empty",
+			want: DurationStats{},
+		},
+		{
+			name:      "single run has no deviation",
+			durations: ms(7),
+			want: DurationStats{
+				Count: 1, Mean: 7 * time.Millisecond, Min: 7 * time.Millisecond, Max: 7 * time.Millisecond,
+				P50: 7 * time.Millisecond, P90: 7 * time.Millisecond, P95: 7 * time.Millisecond, P99: 7 * time.Millisecond,
+			},
+		},
+		{
+			name:      "unsorted input uses sample deviation and interpolated percentiles",
+			durations: ms(5, 1, 4, 2, 3),
+			want: DurationStats{
+				Count:  5,
+				Mean:   3 * time.Millisecond,
+				Min:    1 * time.Millisecond,
+				Max:    5 * time.Millisecond,
+				StdDev: time.Duration(math.Sqrt(2.5) * float64(time.Millisecond)),
+				P50:    3 * time.Millisecond,
+				P90:    4600 * time.Microsecond,
+				P95:    4800 * time.Microsecond,
+				P99:    4960 * time.Microsecond,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Interpolated percentiles may be off by rounding
+			got := ComputeDurationStats(tt.durations)
+			if !closeStats(got, tt.want) {
+				t.Errorf("ComputeDurationStats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func closeStats(a, b DurationStats) bool {
+	near := func(x, y time.Duration) bool { return (x - y).Abs() <= time.Microsecond }
+	return a.Count == b.Count && near(a.Mean, b.Mean) && near(a.Min, b.Min) && near(a.Max, b.Max) &&
+		near(a.StdDev, b.StdDev) && near(a.P50, b.P50) && near(a.P90, b.P90) && near(a.P95, b.P95) && near(a.P99, b.P99)
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := ms(10, 20, 30, 40)
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{0, 10 * time.Millisecond},
+		{50, 25 * time.Millisecond},
+		{100, 40 * time.Millisecond},
+		{90, 37 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		if got := Percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("Percentile(%g) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+	if got := Percentile(nil, 50); got != 0 {
+		t.Errorf("Percentile of no durations = %v, want 0", got)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	run := func(status RunStatus, failure FailureKind, offset, duration time.Duration, rss int64) RunResult {
+		return RunResult{
+			Status:     status,
+			Success:    status == StatusSuccess,
+			Failure:    failure,
+			StartedAt:  start.Add(offset),
+			FinishedAt: start.Add(offset + duration),
+			Duration:   duration,
+			Usage:      ResourceUsage{MaxRSS: rss},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		results     []RunResult
+		executed    int
+		skipped     int
+		successRate float64
+		measured    int
+		rssMean     int64
+		rssPeak     int64
+		throughput  float64
+		wallTime    time.Duration
+	}{
+		{
+			name: "every run measured",
+			results: []RunResult{
+				run(StatusSuccess, FailureNone, 0, time.Second, 100),
+				run(StatusFailed, FailureExitNonZero, time.Second, time.Second, 300),
+			},
+			executed: 2, successRate: 50, measured: 2,
+			rssMean: 200, rssPeak: 300, throughput: 1, wallTime: 2 * time.Second,
+		},
+		{
+			name: "hook failures and skipped runs are not measured",
+			results: []RunResult{
+				run(StatusSuccess, FailureNone, 0, time.Second, 100),
+				run(StatusFailed, FailureHook, time.Second, 0, 0),
+				run(StatusSuccess, FailureNone, time.Second, time.Second, 300),
+				{Status: StatusSkipped},
+			},
+			executed: 3, skipped: 1, successRate: 200.0 / 3, measured: 2,
+			rssMean: 200, rssPeak: 300, throughput: 1, wallTime: 2 * time.Second,
+		},
+		{
+			name: "start errors have a duration but no resource usage",
+			results: []RunResult{
+				run(StatusSuccess, FailureNone, 0, time.Second, 400),
+				run(StatusFailed, FailureStartError, time.Second, 0, 0),
+			},
+			executed: 2, successRate: 50, measured: 2,
+			rssMean: 400, rssPeak: 400, throughput: 2, wallTime: time.Second,
+		},
+		{
+			name:    "nothing executed",
+			results: []RunResult{{Status: StatusSkipped}},
+			skipped: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Summarize(tt.results)
+			if s.Executed != tt.executed || s.Skipped != tt.skipped {
+				t.Errorf("executed %d, skipped %d, want %d and %d", s.Executed, s.Skipped, tt.executed, tt.skipped)
+			}
+			if math.Abs(s.SuccessRate-tt.successRate) > 1e-9 {
+				t.Errorf("SuccessRate = %g, want %g", s.SuccessRate, tt.successRate)
+			}
+			if s.Durations.Count != tt.measured {
+				t.Errorf("measured %d durations, want %d", s.Durations.Count, tt.measured)
+			}
+			if s.Resources.MaxRSSMean != tt.rssMean || s.Resources.MaxRSSPeak != tt.rssPeak {
+				t.Errorf("max RSS mean %d, peak %d, want %d and %d",
+					s.Resources.MaxRSSMean, s.Resources.MaxRSSPeak, tt.rssMean, tt.rssPeak)
+			}
+			if s.WallTime != tt.wallTime || math.Abs(s.Throughput-tt.throughput) > 1e-9 {
+				t.Errorf("throughput %g over %v, want %g over %v", s.Throughput, s.WallTime, tt.throughput, tt.wallTime)
+			}
+		})
+	}
+}
+
+func TestStudentT(t *testing.T) {
+	// Reference values from statistical tables
+	tests := []struct {
+		p, df, t float64
+	}{
+		{0.75, 1, 1},
+		{0.975, 10, 2.228139},
+		{0.975, 30, 2.042272},
+		{0.95, 5, 2.015048},
+		{0.995, 2, 9.924843},
+		{0.5, 7, 0},
+	}
+
+	for _, tt := range tests {
+		if got := StudentTCDF(tt.t, tt.df); math.Abs(got-tt.p) > 1e-6 {
+			t.Errorf("StudentTCDF(%g, %g) = %g, want %g", tt.t, tt.df, got, tt.p)
+		}
+		if got := StudentTCDF(-tt.t, tt.df); math.Abs(got-(1-tt.p)) > 1e-6 {
+			t.Errorf("StudentTCDF(%g, %g) = %g, want %g", -tt.t, tt.df, got, 1-tt.p)
+		}
+		if got := StudentTQuantile(tt.p, tt.df); math.Abs(got-tt.t) > 1e-5 {
+			t.Errorf("StudentTQuantile(%g, %g) = %g, want %g", tt.p, tt.df, got, tt.t)
+		}
+	}
+
+	if !math.IsInf(StudentTQuantile(0, 3), -1) || !math.IsInf(StudentTQuantile(1, 3), 1) {
+		t.Error("StudentTQuantile should be infinite at p = 0 and p = 1")
+	}
+}
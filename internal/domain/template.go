@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// RunInfo identifies an iteration to the command it runs, through environment
+// variables and argument templates such as {{.ID}}.
+type RunInfo struct {
+	ID      int // Unique run ID
+	Attempt int // 1-based iteration number of the command
+	Total   int // Planned number of runs of the command, 0 when open-ended
+	Worker  int // 1-based worker slot executing the run
+	// CommandIndex is the 1-based position of the command in a comparison, 0 otherwise
+	CommandIndex int
+	// ScratchDir is a private temporary directory, archived with the artifacts when the run fails
+	ScratchDir string
+	Warmup     bool // Warmup iterations are numbered separately from measured runs
+}
+
+func NewRunInfo(cfg *RunConfig, id, worker int) RunInfo {
+	index, attempt := cfg.slot(id, cfg.Times)
+	info := RunInfo{
+		ID:           id,
+		Attempt:      attempt,
+		Worker:       worker,
+		CommandIndex: index,
+	}
+	if !cfg.OpenEnded() {
+		info.Total = cfg.Times
+	}
+	return info
+}
+
+// NewWarmupInfo describes the n-th warmup iteration, where every command gets cfg.Warmup of them.
+func NewWarmupInfo(cfg *RunConfig, n, worker int) RunInfo {
+	info := NewRunInfo(cfg, n, worker)
+	info.CommandIndex, info.Attempt = cfg.slot(n, cfg.Warmup)
+	info.Warmup = true
+	return info
+}
+
+// Env returns the AGAIN_* variables describing the run.
+func (i RunInfo) Env() []string {
+	env := []string{
+		"AGAIN_RUN_ID=" + strconv.Itoa(i.ID),
+		"AGAIN_TOTAL=" + strconv.Itoa(i.Total),
+		"AGAIN_ATTEMPT=" + strconv.Itoa(i.Attempt),
+		"AGAIN_WORKER=" + strconv.Itoa(i.Worker),
+		"AGAIN_WARMUP=" + strconv.FormatBool(i.Warmup),
+	}
+	if i.CommandIndex > 0 {
+		env = append(env, "AGAIN_COMMAND_INDEX="+strconv.Itoa(i.CommandIndex))
+	}
+	if i.ScratchDir != "" {
+		env = append(env, "AGAIN_SCRATCH_DIR="+i.ScratchDir)
+	}
+	return env
+}
+
+// ExpandCommand renders the run's templates into command when they are enabled. Otherwise
+// command is returned as is, so that templates meant for the command itself, such as
+// docker inspect -f '{{.State.Status}}', reach it verbatim.
+func (c *RunConfig) ExpandCommand(command []string, info RunInfo) ([]string, error) {
+	if !c.Templates {
+		return command, nil
+	}
+	return ExpandCommand(command, info)
+}
+
+// ExpandCommand renders every argument containing a template action against info.
+func ExpandCommand(command []string, info RunInfo) ([]string, error) {
+	expanded := make([]string, len(command))
+
+	for i, arg := range command {
+		if !strings.Contains(arg, "{{") {
+			expanded[i] = arg
+			continue
+		}
+
+		tmpl, err := template.New("arg").Option("missingkey=error").Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template in argument %q: %w", arg, err)
+		}
+
+		var sb strings.Builder
+		if err := tmpl.Execute(&sb, info); err != nil {
+			return nil, fmt.Errorf("invalid template in argument %q: %w", arg, err)
+		}
+		expanded[i] = sb.String()
+	}
+
+	return expanded, nil
+}
@@ -0,0 +1,147 @@
+package domain
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// ParserKind names the format a run's test results are parsed from.
+type ParserKind string
+
+const (
+	ParserNone   ParserKind = ""
+	ParserGoTest ParserKind = "gotest" // go test -json event stream
+	ParserTAP    ParserKind = "tap"    // Test Anything Protocol, as emitted by node, prove or pytest-tap
+	ParserJUnit  ParserKind = "junit"  // JUnit XML report, as written by pytest, jest-junit or cargo2junit
+)
+
+// PackageTest names the outcome recorded when a package or suite fails outside of its test cases.
+const PackageTest = "(package)"
+
+type TestStatus string
+
+const (
+	TestPass TestStatus = "pass"
+	TestFail TestStatus = "fail"
+	TestSkip TestStatus = "skip"
+)
+
+// TestOutcome is the result of one test case within a run.
+type TestOutcome struct {
+	Package  string // Package, suite or file the test belongs to, if the format has one
+	Name     string
+	Status   TestStatus
+	Duration time.Duration
+}
+
+// TestCounts tallies the test outcomes of a single run.
+type TestCounts struct {
+	Passed  int
+	Failed  int
+	Skipped int
+}
+
+// TestCounts counts the run's parsed test outcomes by status.
+func (r RunResult) TestCounts() TestCounts {
+	var counts TestCounts
+	for _, test := range r.Tests {
+		switch test.Status {
+		case TestPass:
+			counts.Passed++
+		case TestFail:
+			counts.Failed++
+		case TestSkip:
+			counts.Skipped++
+		}
+	}
+	return counts
+}
+
+// FailedTests returns the outcomes of the run's failed tests.
+func (r RunResult) FailedTests() []TestOutcome {
+	var failed []TestOutcome
+	for _, test := range r.Tests {
+		if test.Status == TestFail {
+			failed = append(failed, test)
+		}
+	}
+	return failed
+}
+
+// TestStats aggregates the outcomes of one test across runs.
+type TestStats struct {
+	Package     string
+	Name        string
+	Runs        int // Runs that reported the test
+	Passed      int
+	Failed      int
+	Skipped     int
+	FailureRate float64 // Percentage of reporting runs in which the test failed
+}
+
+// Flaky reports whether the test both passed and failed across runs.
+func (s TestStats) Flaky() bool {
+	return s.Passed > 0 && s.Failed > 0
+}
+
+type testKey struct {
+	pkg  string
+	name string
+}
+
+// TestCollector aggregates per-test outcomes across runs.
+type TestCollector struct {
+	tests map[testKey]*TestStats
+}
+
+func NewTestCollector() *TestCollector {
+	return &TestCollector{tests: make(map[testKey]*TestStats)}
+}
+
+func (c *TestCollector) Add(result RunResult) {
+	for _, test := range result.Tests {
+		key := testKey{pkg: test.Package, name: test.Name}
+		stats, ok := c.tests[key]
+		if !ok {
+			stats = &TestStats{Package: test.Package, Name: test.Name}
+			c.tests[key] = stats
+		}
+
+		stats.Runs++
+		switch test.Status {
+		case TestPass:
+			stats.Passed++
+		case TestFail:
+			stats.Failed++
+		case TestSkip:
+			stats.Skipped++
+		}
+		stats.FailureRate = float64(stats.Failed) * 100 / float64(stats.Runs)
+	}
+}
+
+// Stats returns every test seen, most frequently failing first.
+func (c *TestCollector) Stats() []TestStats {
+	stats := make([]TestStats, 0, len(c.tests))
+	for _, s := range c.tests {
+		stats = append(stats, *s)
+	}
+	slices.SortFunc(stats, func(a, b TestStats) int {
+		return cmp.Or(
+			cmp.Compare(b.Failed, a.Failed),
+			cmp.Compare(a.Package, b.Package),
+			cmp.Compare(a.Name, b.Name),
+		)
+	})
+	return stats
+}
+
+// SummarizeTests aggregates per-test outcomes from a complete set of results.
+func SummarizeTests(results []RunResult) []TestStats {
+	c := NewTestCollector()
+	for _, result := range results {
+		c.Add(result)
+	}
+	return c.Stats()
+}
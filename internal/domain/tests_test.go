@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSummarizeTests(t *testing.T) {
+	run := func(tests ...TestOutcome) RunResult {
+		return RunResult{Tests: tests}
+	}
+	pass := func(pkg, name string) TestOutcome { return TestOutcome{Package: pkg, Name: name, Status: TestPass} }
+	fail := func(pkg, name string) TestOutcome { return TestOutcome{Package: pkg, Name: name, Status: TestFail} }
+	skip := func(pkg, name string) TestOutcome { return TestOutcome{Package: pkg, Name: name, Status: TestSkip} }
+
+	tests := []struct {
+		name    string
+		results []RunResult
+		want    []TestStats
+	}{
+		{
+			name:    "no tests",
+			results: []RunResult{run(), run()},
+			want:    []TestStats{},
+		},
+		{
+			name: "most frequently failing first, then by package and name",
+			results: []RunResult{
+				run(pass("a", "TestOK"), fail("b", "TestFlaky"), fail("a", "TestBroken"), skip("a", "TestSkipped")),
+				run(pass("a", "TestOK"), pass("b", "TestFlaky"), fail("a", "TestBroken"), skip("a", "TestSkipped")),
+				run(pass("a", "TestOK"), fail("a", "TestBroken")),
+			},
+			want: []TestStats{
+				{Package: "a", Name: "TestBroken", Runs: 3, Failed: 3, FailureRate: 100},
+				{Package: "b", Name: "TestFlaky", Runs: 2, Passed: 1, Failed: 1, FailureRate: 50},
+				{Package: "a", Name: "TestOK", Runs: 3, Passed: 3},
+				{Package: "a", Name: "TestSkipped", Runs: 2, Skipped: 2},
+			},
+		},
+		{
+			name: "same name in different packages",
+			results: []RunResult{
+				run(fail("a", "TestX"), pass("b", "TestX")),
+			},
+			want: []TestStats{
+				{Package: "a", Name: "TestX", Runs: 1, Failed: 1, FailureRate: 100},
+				{Package: "b", Name: "TestX", Runs: 1, Passed: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SummarizeTests(tt.results)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SummarizeTests() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTestStatsFlaky(t *testing.T) {
+	tests := []struct {
+		stats TestStats
+		want  bool
+	}{
+		{TestStats{Passed: 3}, false},
+		{TestStats{Failed: 3}, false},
+		{TestStats{Passed: 1, Failed: 1}, true},
+		{TestStats{Skipped: 1, Failed: 1}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.stats.Flaky(); got != tt.want {
+			t.Errorf("%+v.Flaky() = %v, want %v", tt.stats, got, tt.want)
+		}
+	}
+}
+
+func TestRunResultTestCounts(t *testing.T) {
+	result := RunResult{Tests: []TestOutcome{
+		{Name: "a", Status: TestPass},
+		{Name: "b", Status: TestFail},
+		{Name: "c", Status: TestPass},
+		{Name: "d", Status: TestSkip},
+	}}
+
+	if got, want := result.TestCounts(), (TestCounts{Passed: 2, Failed: 1, Skipped: 1}); got != want {
+		t.Errorf("TestCounts() = %+v, want %+v", got, want)
+	}
+	if got := result.FailedTests(); len(got) != 1 || got[0].Name != "b" {
+		t.Errorf("FailedTests() = %+v, want test b", got)
+	}
+}
@@ -11,9 +11,10 @@ func NewConfigValidator() *ConfigValidator {
 	return &ConfigValidator{}
 }
 
-func validateFormat(format OutputFormat) error {
+// ValidateFormat checks that format names one of the output formats.
+func ValidateFormat(format OutputFormat) error {
 	switch format {
-	case FormatRaw, FormatJSON, FormatTUI:
+	case FormatRaw, FormatJSON, FormatNDJSON, FormatJUnit, FormatTUI:
 		return nil
 	default:
 		return fmt.Errorf("invalid output format: %s", format)
@@ -29,21 +30,184 @@ func validateVerbosity(verbosity VerbosityLevel) error {
 	}
 }
 
+func validateRetention(retention RetentionMode) error {
+	switch retention {
+	case "", RetainHead, RetainHeadTail:
+		return nil
+	default:
+		return fmt.Errorf("invalid output retention: %s", retention)
+	}
+}
+
+func validateParser(parser ParserKind) error {
+	switch parser {
+	case ParserNone, ParserGoTest, ParserTAP, ParserJUnit:
+		return nil
+	default:
+		return fmt.Errorf("invalid parser: %s", parser)
+	}
+}
+
+func validateUntil(cfg *RunConfig) error {
+	switch cfg.Until {
+	case UntilNone, UntilFail, UntilSuccess:
+		return nil
+	case UntilStreak:
+		if cfg.Streak < 1 {
+			return errors.New("until streak must be at least 1")
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid until mode: %s", cfg.Until)
+	}
+}
+
 func (v *ConfigValidator) Validate(cfg *RunConfig) error {
+	for i, command := range cfg.Commands {
+		if len(command) == 0 {
+			return fmt.Errorf("command %d of the comparison cannot be empty", i+1)
+		}
+		info := NewRunInfo(cfg, 1, 1)
+		info.CommandIndex = i + 1
+		if _, err := cfg.ExpandCommand(command, info); err != nil {
+			return fmt.Errorf("command %d: %w", i+1, err)
+		}
+	}
+
 	if len(cfg.Command) == 0 {
 		return errors.New("command cannot be empty")
 	}
 
-	if cfg.Times < 1 {
-		return errors.New("times must be at least 1")
+	// Catch template errors before the first run instead of failing every iteration
+	if _, err := cfg.ExpandCommand(cfg.Command, NewRunInfo(cfg, 1, 1)); err != nil {
+		return err
+	}
+
+	for phase, hook := range cfg.HookCommands() {
+		if _, err := cfg.ExpandCommand(hook, NewRunInfo(cfg, 1, 1)); err != nil {
+			return cfg.Sources.Wrap(string(phase), fmt.Errorf("%s hook: %w", phase, err))
+		}
+	}
+
+	if err := validateUntil(cfg); err != nil {
+		return cfg.Sources.Wrap("until-streak", err)
+	}
+
+	if cfg.TimeBudget < 0 {
+		return cfg.Sources.Wrap("for", errors.New("time budget cannot be negative"))
+	}
+
+	if cfg.KillAtDeadline && cfg.TimeBudget == 0 {
+		return cfg.Sources.Wrap("kill-at-deadline", errors.New("kill at deadline requires a time budget"))
+	}
+
+	if cfg.Comparing() && cfg.OpenEnded() {
+		return errors.New("comparing commands requires a fixed number of runs, --for and until modes are not supported")
+	}
+
+	if cfg.Comparing() && (cfg.SaveBaseline != "" || cfg.Baseline != "") {
+		return errors.New("baselines cover a single command and cannot be used when comparing commands")
+	}
+
+	if cfg.Threshold < 0 || cfg.Threshold >= 100 {
+		return cfg.Sources.Wrap("threshold", fmt.Errorf("threshold must be at least 0%% and below 100%%, got %g%%", cfg.Threshold))
+	}
+
+	if cfg.Interleave && !cfg.Comparing() {
+		return cfg.Sources.Wrap("interleave", errors.New("interleave requires several commands separated by :::"))
+	}
+
+	if cfg.OpenEnded() {
+		if cfg.Times < 0 {
+			return cfg.Sources.Wrap("max", errors.New("max cannot be negative"))
+		}
+	} else if cfg.Times < 1 {
+		return cfg.Sources.Wrap("times", errors.New("times must be at least 1"))
 	}
 
-	if err := validateFormat(cfg.Format); err != nil {
+	if cfg.Warmup < 0 {
+		return cfg.Sources.Wrap("warmup", errors.New("warmup cannot be negative"))
+	}
+
+	if cfg.IncludeWarmup && cfg.Warmup == 0 {
+		return cfg.Sources.Wrap("include-warmup", errors.New("include warmup requires warmup iterations"))
+	}
+
+	if cfg.Parallel < 1 {
+		return cfg.Sources.Wrap("parallel", errors.New("parallel must be at least 1"))
+	}
+
+	if cfg.Timeout < 0 {
+		return cfg.Sources.Wrap("timeout", errors.New("timeout cannot be negative"))
+	}
+
+	if cfg.KillGrace < 0 {
+		return cfg.Sources.Wrap("kill-grace", errors.New("kill grace cannot be negative"))
+	}
+
+	if cfg.Interval < 0 {
+		return cfg.Sources.Wrap("interval", errors.New("interval cannot be negative"))
+	}
+
+	if cfg.Jitter < 0 {
+		return cfg.Sources.Wrap("jitter", errors.New("jitter cannot be negative"))
+	}
+
+	if cfg.Rate < 0 {
+		return cfg.Sources.Wrap("rate", errors.New("rate cannot be negative"))
+	}
+
+	if cfg.MaxOutput < 0 {
+		return cfg.Sources.Wrap("max-output", errors.New("max output cannot be negative"))
+	}
+
+	if cfg.ArtifactsFailedOnly && cfg.ArtifactsDir == "" {
+		return cfg.Sources.Wrap("artifacts-failed-only", errors.New("keeping only failed artifacts requires an artifacts directory"))
+	}
+
+	if cfg.HistoryKeep < 0 {
+		return cfg.Sources.Wrap("history-keep", errors.New("history keep cannot be negative"))
+	}
+
+	if cfg.HistoryMaxSize < 0 {
+		return cfg.Sources.Wrap("history-max-size", errors.New("history max size cannot be negative"))
+	}
+
+	if err := validateRetention(cfg.OutputRetention); err != nil {
+		return cfg.Sources.Wrap("output-retention", err)
+	}
+
+	if err := validateParser(cfg.Parser); err != nil {
+		return cfg.Sources.Wrap("parse", err)
+	}
+
+	if cfg.ParseFile != "" {
+		if cfg.Parser == ParserNone {
+			return cfg.Sources.Wrap("parse-file", errors.New("parse file requires a parser"))
+		}
+		if _, err := ExpandCommand([]string{cfg.ParseFile}, NewRunInfo(cfg, 1, 1)); err != nil {
+			return cfg.Sources.Wrap("parse-file", fmt.Errorf("parse file: %w", err))
+		}
+	}
+
+	if _, err := NewSuccessCriteria(cfg); err != nil {
 		return err
 	}
 
+	if cfg.MaxFailures < 0 {
+		return cfg.Sources.Wrap("max-failures", errors.New("max failures cannot be negative"))
+	}
+
+	if cfg.MaxFailureRate < 0 || cfg.MaxFailureRate > 100 {
+		return cfg.Sources.Wrap("max-failure-rate", fmt.Errorf("max failure rate must be between 0%% and 100%%, got %g%%", cfg.MaxFailureRate))
+	}
+
+	if err := ValidateFormat(cfg.Format); err != nil {
+		return cfg.Sources.Wrap("format", err)
+	}
+
 	if err := validateVerbosity(cfg.Verbosity); err != nil {
-		return err
+		return cfg.Sources.Wrap("verbosity", err)
 	}
 
 	return nil
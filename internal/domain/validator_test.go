@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateTemplates(t *testing.T) {
+	tests := []struct {
+		name      string
+		command   []string
+		beforeRun string
+		templates bool
+		err       string // Substring of the expected error, empty when the config is valid
+	}{
+		{"foreign templates pass through", []string{"docker", "inspect", "-f", "{{.State.Status}}"}, "", false, ""},
+		{"unparsable braces pass through", []string{"echo", "{{"}, "echo {{.Foo}}", false, ""},
+		{"known fields", []string{"./bench.sh", "--seed", "{{.ID}}"}, "echo {{.Attempt}}", true, ""},
+		{"unknown field", []string{"echo", "{{.Foo}}"}, "", true, "can't evaluate field Foo"},
+		{"invalid syntax", []string{"echo", "{{"}, "", true, "invalid template"},
+		{"unknown field in a hook", []string{"true"}, "echo {{.Foo}}", true, "before-each hook"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &RunConfig{
+				Command:    tt.command,
+				Times:      1,
+				Parallel:   1,
+				Verbosity:  VerbosityNormal,
+				Format:     FormatJSON,
+				BeforeEach: tt.beforeRun,
+				Templates:  tt.templates,
+			}
+			err := NewConfigValidator().Validate(cfg)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want no error", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("Validate() = %v, want an error containing %q", err, tt.err)
+			}
+		})
+	}
+}
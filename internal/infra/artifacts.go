@@ -0,0 +1,251 @@
+package infra
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/msaeedsaeedi/again/internal/domain"
+)
+
+// ArtifactMeta is the meta.json written next to a run's output.
+type ArtifactMeta struct {
+	ID           int       `json:"id"`
+	Worker       int       `json:"worker"`
+	Command      []string  `json:"command"`
+	CommandIndex int       `json:"command_index,omitempty"`
+	Status       string    `json:"status"`
+	Success      bool      `json:"success"`
+	Failure      string    `json:"failure,omitempty"`
+	ExitCode     int       `json:"exit_code"`
+	Signal       string    `json:"signal,omitempty"`
+	CoreDumped   bool      `json:"core_dumped,omitempty"`
+	Reason       string    `json:"reason,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	StartedAt    time.Time `json:"started_at"`
+	FinishedAt   time.Time `json:"finished_at"`
+	Duration     float64   `json:"duration_ms"`
+	Scratch      bool      `json:"scratch_archived,omitempty"`
+}
+
+// ArtifactStore persists the output and metadata of every run under run-NNNN directories.
+type ArtifactStore struct {
+	dir        string
+	failedOnly bool
+}
+
+// NewArtifactStore returns nil when no artifact directory is configured. It removes
+// the run directories of previous sessions, so that a shorter session never leaves
+// stale runs behind, but keeps any other files in the directory.
+func NewArtifactStore(cfg *domain.RunConfig) (*ArtifactStore, error) {
+	if cfg.ArtifactsDir == "" {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(cfg.ArtifactsDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create artifacts directory: %w", err)
+	}
+	if err := clearRunDirs(cfg.ArtifactsDir); err != nil {
+		return nil, fmt.Errorf("clear artifacts directory: %w", err)
+	}
+
+	return &ArtifactStore{
+		dir:        cfg.ArtifactsDir,
+		failedOnly: cfg.ArtifactsFailedOnly,
+	}, nil
+}
+
+// Save writes stdout, stderr and meta.json for the run, archiving its scratch
+// directory when the run failed. It returns the run's artifact directory, or
+// an empty string when the run is not kept.
+func (s *ArtifactStore) Save(result domain.RunResult, info domain.RunInfo) (string, error) {
+	if s == nil {
+		return "", nil
+	}
+
+	failed := !result.Success && result.Status != domain.StatusSkipped
+	if s.failedOnly && !failed {
+		return "", nil
+	}
+
+	dir := filepath.Join(s.dir, fmt.Sprintf("run-%04d", result.ID))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create artifacts of run %d: %w", result.ID, err)
+	}
+
+	if err := writeStream(filepath.Join(dir, "stdout"), result.Stdout, result.StdoutPath); err != nil {
+		return "", fmt.Errorf("save stdout of run %d: %w", result.ID, err)
+	}
+	if err := writeStream(filepath.Join(dir, "stderr"), result.Stderr, result.StderrPath); err != nil {
+		return "", fmt.Errorf("save stderr of run %d: %w", result.ID, err)
+	}
+
+	meta := newArtifactMeta(result, info)
+	if failed && info.ScratchDir != "" {
+		archived, err := copyTree(info.ScratchDir, filepath.Join(dir, "scratch"))
+		if err != nil {
+			return "", fmt.Errorf("archive scratch directory of run %d: %w", result.ID, err)
+		}
+		meta.Scratch = archived
+	}
+
+	if err := writeMeta(filepath.Join(dir, "meta.json"), meta); err != nil {
+		return "", fmt.Errorf("save metadata of run %d: %w", result.ID, err)
+	}
+
+	return dir, nil
+}
+
+// clearRunDirs removes the run-NNNN directories in dir.
+func clearRunDirs(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || !isRunDirName(entry.Name()) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isRunDirName(name string) bool {
+	digits, ok := strings.CutPrefix(name, "run-")
+	if !ok || digits == "" {
+		return false
+	}
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func newArtifactMeta(result domain.RunResult, info domain.RunInfo) ArtifactMeta {
+	meta := ArtifactMeta{
+		ID:           result.ID,
+		Worker:       info.Worker,
+		Command:      result.Command,
+		CommandIndex: result.CommandIndex,
+		Status:       string(result.Status),
+		Success:      result.Success,
+		Failure:      string(result.Failure),
+		ExitCode:     result.ExitCode,
+		Signal:       result.Signal,
+		CoreDumped:   result.CoreDumped,
+		Reason:       result.Reason,
+		StartedAt:    result.StartedAt,
+		FinishedAt:   result.FinishedAt,
+		Duration:     float64(result.Duration) / float64(time.Millisecond),
+	}
+	if result.Error != nil {
+		meta.Error = result.Error.Error()
+	}
+	return meta
+}
+
+func writeMeta(path string, meta ArtifactMeta) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("", "  ")
+	// Commands routinely contain shell redirections
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(meta); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// writeStream copies spooled output when there is a spool file, and the retained bytes otherwise.
+func writeStream(dst string, data []byte, spoolPath string) error {
+	if spoolPath == "" {
+		return os.WriteFile(dst, data, 0o644)
+	}
+
+	src, err := os.Open(spoolPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	return copyFile(dst, src, 0o644)
+}
+
+func copyFile(dst string, src io.Reader, perm fs.FileMode) error {
+	f, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, src); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// copyTree copies regular files, directories and symlinks from src into dst.
+// It reports whether src contained anything worth archiving.
+func copyTree(src, dst string) (bool, error) {
+	entries, err := os.ReadDir(src)
+	if err != nil || len(entries) == 0 {
+		return false, err
+	}
+
+	err = filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, 0o755)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			return copyFile(target, f, info.Mode().Perm())
+		default:
+			// Sockets, pipes and devices cannot be archived meaningfully
+			return nil
+		}
+	})
+	return err == nil, err
+}
+
+// NewScratchDir creates the private temporary directory handed to a run.
+func NewScratchDir(runID int) (string, error) {
+	dir, err := os.MkdirTemp("", fmt.Sprintf("again-run-%04d-", runID))
+	if err != nil {
+		return "", fmt.Errorf("create scratch directory: %w", err)
+	}
+	return dir, nil
+}
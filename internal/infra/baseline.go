@@ -0,0 +1,94 @@
+package infra
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/msaeedsaeedi/again/internal/domain"
+)
+
+// baselineVersion is bumped whenever the file format changes incompatibly.
+const baselineVersion = 1
+
+// BaselineFile is the JSON document written by --save-baseline.
+type BaselineFile struct {
+	Version     int       `json:"version"`
+	CreatedAt   time.Time `json:"created_at"`
+	Command     []string  `json:"command"`
+	Executed    int       `json:"executed"`
+	Succeeded   int       `json:"succeeded"`
+	SuccessRate float64   `json:"success_rate"`
+	MeanMs      float64   `json:"mean_ms"`
+	StdDevMs    float64   `json:"stddev_ms"`
+	DurationsMs []float64 `json:"durations_ms"`
+}
+
+// SaveBaseline writes baseline to path, replacing any previous file.
+func SaveBaseline(path string, baseline domain.Baseline) error {
+	stats := domain.ComputeDurationStats(baseline.Durations)
+	file := BaselineFile{
+		Version:     baselineVersion,
+		CreatedAt:   baseline.CreatedAt,
+		Command:     baseline.Command,
+		Executed:    baseline.Executed,
+		Succeeded:   baseline.Succeeded,
+		SuccessRate: baseline.SuccessRate,
+		MeanMs:      milliseconds(stats.Mean),
+		StdDevMs:    milliseconds(stats.StdDev),
+		DurationsMs: make([]float64, 0, len(baseline.Durations)),
+	}
+	for _, d := range baseline.Durations {
+		file.DurationsMs = append(file.DurationsMs, milliseconds(d))
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(file); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("save baseline: %w", err)
+	}
+	return nil
+}
+
+// LoadBaseline reads a file written by SaveBaseline.
+func LoadBaseline(path string) (domain.Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return domain.Baseline{}, fmt.Errorf("load baseline: %w", err)
+	}
+
+	var file BaselineFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return domain.Baseline{}, fmt.Errorf("%s: invalid baseline: %w", path, err)
+	}
+	if file.Version != baselineVersion {
+		return domain.Baseline{}, fmt.Errorf("%s: unsupported baseline version %d", path, file.Version)
+	}
+	if len(file.DurationsMs) == 0 {
+		return domain.Baseline{}, fmt.Errorf("%s: baseline has no measured runs", path)
+	}
+
+	baseline := domain.Baseline{
+		Command:     file.Command,
+		CreatedAt:   file.CreatedAt,
+		Executed:    file.Executed,
+		Succeeded:   file.Succeeded,
+		SuccessRate: file.SuccessRate,
+		Durations:   make([]time.Duration, 0, len(file.DurationsMs)),
+	}
+	for _, ms := range file.DurationsMs {
+		baseline.Durations = append(baseline.Durations, time.Duration(ms*float64(time.Millisecond)))
+	}
+	return baseline, nil
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
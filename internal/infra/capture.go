@@ -0,0 +1,152 @@
+package infra
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/msaeedsaeedi/again/internal/domain"
+)
+
+// outputCapture retains what a run wrote to one stream.
+type outputCapture interface {
+	Write(p []byte) (int, error)
+	// Bytes returns the retained output, nil when it was spooled to disk.
+	Bytes() []byte
+	// Path returns the spool file, empty when output is kept in memory.
+	Path() string
+	Close() error
+}
+
+// newOutputCapture spools to name.stream in the spool directory when there is one.
+func newOutputCapture(cfg *domain.RunConfig, name, stream string) (outputCapture, error) {
+	limit := cfg.MaxOutput
+	if limit <= 0 {
+		limit = domain.DefaultMaxOutput
+	}
+
+	if cfg.SpoolDir != "" {
+		if err := os.MkdirAll(cfg.SpoolDir, 0o755); err != nil {
+			return nil, fmt.Errorf("create spool directory: %w", err)
+		}
+		path := filepath.Join(cfg.SpoolDir, name+"."+stream)
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, fmt.Errorf("create spool file: %w", err)
+		}
+		return &fileCapture{file: f}, nil
+	}
+
+	if cfg.OutputRetention == domain.RetainHeadTail {
+		return &headTailBuffer{headLimit: limit / 2, tailLimit: limit - limit/2}, nil
+	}
+	return &limitedBuffer{buf: &bytes.Buffer{}, limit: int(limit)}, nil
+}
+
+type limitedBuffer struct {
+	buf       *bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (lb *limitedBuffer) Write(p []byte) (n int, err error) {
+	if lb.buf.Len() >= lb.limit {
+		lb.truncated = true
+		return len(p), nil
+	}
+
+	available := lb.limit - lb.buf.Len()
+	if len(p) > available {
+		lb.buf.Write(p[:available])
+		lb.truncated = true
+		return len(p), nil
+	}
+
+	return lb.buf.Write(p)
+}
+
+func (lb *limitedBuffer) Bytes() []byte {
+	if !lb.truncated {
+		return lb.buf.Bytes()
+	}
+	// Add warning if output was truncated
+	msg := fmt.Sprintf("\n[OUTPUT TRUNCATED: exceeded %s limit]\n", formatSize(int64(lb.limit)))
+	return append(lb.buf.Bytes(), msg...)
+}
+
+func (lb *limitedBuffer) Path() string { return "" }
+
+func (lb *limitedBuffer) Close() error { return nil }
+
+// headTailBuffer keeps the first headLimit and the last tailLimit bytes of a stream.
+type headTailBuffer struct {
+	headLimit int64
+	tailLimit int64
+	head      []byte
+	tail      []byte // Grows up to twice tailLimit before being compacted
+	total     int64
+}
+
+func (b *headTailBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	b.total += int64(n)
+
+	if room := b.headLimit - int64(len(b.head)); room > 0 {
+		k := min(room, int64(len(p)))
+		b.head = append(b.head, p[:k]...)
+		p = p[k:]
+	}
+	if len(p) == 0 {
+		return n, nil
+	}
+
+	b.tail = append(b.tail, p...)
+	if int64(len(b.tail)) > 2*b.tailLimit {
+		b.tail = append(b.tail[:0], b.tail[int64(len(b.tail))-b.tailLimit:]...)
+	}
+	return n, nil
+}
+
+func (b *headTailBuffer) Bytes() []byte {
+	tail := b.tail
+	if int64(len(tail)) > b.tailLimit {
+		tail = tail[int64(len(tail))-b.tailLimit:]
+	}
+
+	out := append([]byte{}, b.head...)
+	if omitted := b.total - int64(len(b.head)) - int64(len(tail)); omitted > 0 {
+		out = fmt.Appendf(out, "\n[OUTPUT TRUNCATED: %s omitted]\n", formatSize(omitted))
+	}
+	return append(out, tail...)
+}
+
+func (b *headTailBuffer) Path() string { return "" }
+
+func (b *headTailBuffer) Close() error { return nil }
+
+// fileCapture spools the complete stream to disk.
+type fileCapture struct {
+	file *os.File
+}
+
+func (c *fileCapture) Write(p []byte) (int, error) { return c.file.Write(p) }
+
+func (c *fileCapture) Bytes() []byte { return nil }
+
+func (c *fileCapture) Path() string { return c.file.Name() }
+
+func (c *fileCapture) Close() error { return c.file.Close() }
+
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<30 && n%(1<<30) == 0:
+		return fmt.Sprintf("%dGB", n>>30)
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("%dMB", n>>20)
+	case n >= 1<<10 && n%(1<<10) == 0:
+		return fmt.Sprintf("%dKB", n>>10)
+	default:
+		return fmt.Sprintf("%d bytes", n)
+	}
+}
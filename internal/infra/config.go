@@ -0,0 +1,243 @@
+package infra
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectConfigNames are looked up in the working directory and its parents.
+var ProjectConfigNames = []string{".again.yaml", ".again.yml"}
+
+// ConfigSetting is one option assignment from a configuration file, keyed by flag name.
+type ConfigSetting struct {
+	Key   string
+	Value string // Lists are joined with commas, as they would be given on the command line
+	Line  int
+}
+
+// ConfigFile holds the top-level settings of a configuration file and its named profiles.
+type ConfigFile struct {
+	Path     string
+	Settings []ConfigSetting
+	Profiles map[string][]ConfigSetting
+}
+
+// FindProjectConfig returns the nearest project configuration file at or above dir,
+// or an empty string when there is none.
+func FindProjectConfig(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		for _, name := range ProjectConfigNames {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err == nil {
+				return path, nil
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return "", err
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// UserConfigPath returns the user-level configuration file when it exists.
+func UserConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	path := filepath.Join(dir, "again", "config.yaml")
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// LoadConfigFile parses a configuration file. Errors carry the file and line they refer to.
+func LoadConfigFile(path string) (*ConfigFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, locateError(path, syntaxError(err))
+	}
+
+	file := &ConfigFile{
+		Path:     path,
+		Profiles: make(map[string][]ConfigSetting),
+	}
+	// An empty file has no content at all
+	if len(doc.Content) == 0 {
+		return file, nil
+	}
+
+	root := resolveAlias(doc.Content[0])
+	if isNull(root) {
+		return file, nil
+	}
+	fields, err := mappingFields(root, "the configuration file must be a mapping of options")
+	if err != nil {
+		return nil, locateError(path, err)
+	}
+
+	for _, field := range fields {
+		if field.key != "profiles" {
+			setting, err := newConfigSetting(field)
+			if err != nil {
+				return nil, locateError(path, err)
+			}
+			file.Settings = append(file.Settings, setting)
+			continue
+		}
+
+		if isNull(field.node) {
+			continue
+		}
+		profiles, err := mappingFields(field.node, "profiles must be a mapping of profile names to options")
+		if err != nil {
+			return nil, locateError(path, err)
+		}
+		for _, profile := range profiles {
+			settings := []ConfigSetting{}
+			if !isNull(profile.node) {
+				options, err := mappingFields(profile.node, fmt.Sprintf("profile %q must be a mapping of options", profile.key))
+				if err != nil {
+					return nil, locateError(path, err)
+				}
+				for _, option := range options {
+					setting, err := newConfigSetting(option)
+					if err != nil {
+						return nil, locateError(path, err)
+					}
+					settings = append(settings, setting)
+				}
+			}
+			file.Profiles[profile.key] = settings
+		}
+	}
+
+	return file, nil
+}
+
+// configError is a problem with the contents of a configuration file, located by line.
+type configError struct {
+	line int
+	msg  string
+}
+
+func (e *configError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.line, e.msg)
+}
+
+func configErrorf(line int, format string, args ...any) error {
+	return &configError{line: line, msg: fmt.Sprintf(format, args...)}
+}
+
+// configField is one key of a YAML mapping with the line it is defined on.
+type configField struct {
+	key  string
+	line int
+	node *yaml.Node
+}
+
+// mappingFields lists the keys of a mapping node in order, rejecting duplicates.
+// notMapping is the error reported when node is something else.
+func mappingFields(node *yaml.Node, notMapping string) ([]configField, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, configErrorf(node.Line, "%s", notMapping)
+	}
+
+	var fields []configField
+	seen := make(map[string]int)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := resolveAlias(node.Content[i])
+		if key.Kind != yaml.ScalarNode {
+			return nil, configErrorf(key.Line, "keys must be plain names")
+		}
+		if first, ok := seen[key.Value]; ok {
+			return nil, configErrorf(key.Line, "duplicate key %q (first defined on line %d)", key.Value, first)
+		}
+		seen[key.Value] = key.Line
+		fields = append(fields, configField{key: key.Value, line: key.Line, node: resolveAlias(node.Content[i+1])})
+	}
+	return fields, nil
+}
+
+func newConfigSetting(field configField) (ConfigSetting, error) {
+	setting := ConfigSetting{Key: field.key, Line: field.line}
+
+	switch field.node.Kind {
+	case yaml.MappingNode:
+		return setting, configErrorf(field.line, "option %q expects a value, not a mapping", field.key)
+	case yaml.SequenceNode:
+		values := make([]string, 0, len(field.node.Content))
+		for _, item := range field.node.Content {
+			item = resolveAlias(item)
+			if item.Kind != yaml.ScalarNode {
+				return setting, configErrorf(item.Line, "option %q expects a list of values, not nested structures", field.key)
+			}
+			values = append(values, item.Value)
+		}
+		setting.Value = strings.Join(values, ",")
+	default:
+		if !isNull(field.node) {
+			setting.Value = field.node.Value
+		}
+	}
+	return setting, nil
+}
+
+// syntaxError turns the "yaml: line N: ..." errors of the YAML decoder into configErrors.
+func syntaxError(err error) error {
+	msg := strings.TrimPrefix(err.Error(), "yaml: ")
+	rest, ok := strings.CutPrefix(msg, "line ")
+	if !ok {
+		return errors.New(msg)
+	}
+	number, text, ok := strings.Cut(rest, ": ")
+	line, convErr := strconv.Atoi(number)
+	if !ok || convErr != nil {
+		return errors.New(msg)
+	}
+	return configErrorf(line, "%s", text)
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// isNull reports whether node has no value, as with "key:" or "key: ~".
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
+
+// locateError prefixes a parse error with the file and line it refers to.
+func locateError(path string, err error) error {
+	var cerr *configError
+	if errors.As(err, &cerr) {
+		return fmt.Errorf("%s:%d: %s", path, cerr.line, cerr.msg)
+	}
+	return fmt.Errorf("%s: %w", path, err)
+}
@@ -0,0 +1,163 @@
+package infra
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		settings []ConfigSetting
+		profiles map[string][]ConfigSetting
+		err      string // Substring of the expected error, empty when loading succeeds
+	}{
+		{
+			name:     "empty file",
+			data:     "",
+			profiles: map[string][]ConfigSetting{},
+		},
+		{
+			name:     "only comments",
+			data:     "# nothing yet\n---\n",
+			profiles: map[string][]ConfigSetting{},
+		},
+		{
+			name: "scalars keep their text",
+			data: "times: 20\nfail-fast: true\ntimeout: 30s\nexpect-stdout: 'ok: \\d+'\nsetup: \"make build\" # compile first\nmax-output:\n",
+			settings: []ConfigSetting{
+				{Key: "times", Value: "20", Line: 1},
+				{Key: "fail-fast", Value: "true", Line: 2},
+				{Key: "timeout", Value: "30s", Line: 3},
+				{Key: "expect-stdout", Value: `ok: \d+`, Line: 4},
+				{Key: "setup", Value: "make build", Line: 5},
+				{Key: "max-output", Value: "", Line: 6},
+			},
+			profiles: map[string][]ConfigSetting{},
+		},
+		{
+			name: "lists are joined with commas",
+			data: "success-codes: [0, 1]\nother-codes:\n  - 2\n  - 3\n",
+			settings: []ConfigSetting{
+				{Key: "success-codes", Value: "0,1", Line: 1},
+				{Key: "other-codes", Value: "2,3", Line: 2},
+			},
+			profiles: map[string][]ConfigSetting{},
+		},
+		{
+			name: "profiles",
+			data: "times: 5\nprofiles:\n  soak:\n    for: 10m\n    parallel: 4\n  empty:\n",
+			settings: []ConfigSetting{
+				{Key: "times", Value: "5", Line: 1},
+			},
+			profiles: map[string][]ConfigSetting{
+				"soak": {
+					{Key: "for", Value: "10m", Line: 4},
+					{Key: "parallel", Value: "4", Line: 5},
+				},
+				"empty": {},
+			},
+		},
+		{
+			name: "aliases are resolved",
+			data: "timeout: &t 5s\nprofiles:\n  ci:\n    timeout: *t\n",
+			settings: []ConfigSetting{
+				{Key: "timeout", Value: "5s", Line: 1},
+			},
+			profiles: map[string][]ConfigSetting{
+				"ci": {{Key: "timeout", Value: "5s", Line: 4}},
+			},
+		},
+		{
+			name: "duplicate keys",
+			data: "times: 5\nparallel: 2\ntimes: 6\n",
+			err:  ":3: duplicate key \"times\" (first defined on line 1)",
+		},
+		{
+			name: "mapping as an option value",
+			data: "times:\n  n: 5\n",
+			err:  ":1: option \"times\" expects a value, not a mapping",
+		},
+		{
+			name: "nested lists",
+			data: "success-codes:\n  - [0, 1]\n",
+			err:  ":2: option \"success-codes\" expects a list of values",
+		},
+		{
+			name: "profiles that are not a mapping",
+			data: "profiles: soak\n",
+			err:  ":1: profiles must be a mapping",
+		},
+		{
+			name: "profile that is not a mapping",
+			data: "profiles:\n  soak: [a, b]\n",
+			err:  ":2: profile \"soak\" must be a mapping of options",
+		},
+		{
+			name: "top level that is not a mapping",
+			data: "- times\n",
+			err:  ":1: the configuration file must be a mapping of options",
+		},
+		{
+			name: "invalid syntax",
+			data: "times: 5\n  parallel: 2\n",
+			err:  ":2: mapping values are not allowed in this context",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".again.yaml")
+			if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			file, err := LoadConfigFile(path)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) || !strings.HasPrefix(err.Error(), path) {
+					t.Fatalf("error %v, want one for %s containing %q", err, path, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(file.Settings, tt.settings) {
+				t.Errorf("settings %+v, want %+v", file.Settings, tt.settings)
+			}
+			if !reflect.DeepEqual(file.Profiles, tt.profiles) {
+				t.Errorf("profiles %+v, want %+v", file.Profiles, tt.profiles)
+			}
+		})
+	}
+}
+
+func TestFindProjectConfig(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(root, "a", ".again.yml")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{nested, path},
+		{filepath.Join(root, "a"), path},
+	}
+	for _, tt := range tests {
+		got, err := FindProjectConfig(tt.dir)
+		if err != nil || got != tt.want {
+			t.Errorf("FindProjectConfig(%s) = %q, %v, want %q", tt.dir, got, err, tt.want)
+		}
+	}
+}
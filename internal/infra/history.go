@@ -0,0 +1,631 @@
+package infra
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/msaeedsaeedi/again/internal/domain"
+	bolt "go.etcd.io/bbolt"
+)
+
+// historyVersion is bumped whenever the format of recorded sessions changes incompatibly.
+const historyVersion = 1
+
+// HistorySession is the record of a session, stored apart from its HistoryRun records.
+type HistorySession struct {
+	Version    int            `json:"version"`
+	ID         int            `json:"id"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt time.Time      `json:"finished_at"`
+	Status     string         `json:"status"`
+	Error      string         `json:"error,omitempty"`
+	Config     HistoryConfig  `json:"config"`
+	Summary    HistorySummary `json:"summary"`
+}
+
+// HistoryConfig records the options a session ran with.
+type HistoryConfig struct {
+	Command        []string   `json:"command"`
+	Commands       [][]string `json:"commands,omitempty"`
+	Interleave     bool       `json:"interleave,omitempty"`
+	Times          int        `json:"times"`
+	Parallel       int        `json:"parallel"`
+	Format         string     `json:"format"`
+	TimeoutMs      float64    `json:"timeout_ms,omitempty"`
+	Until          string     `json:"until,omitempty"`
+	Streak         int        `json:"streak,omitempty"`
+	TimeBudgetMs   float64    `json:"time_budget_ms,omitempty"`
+	FailFast       bool       `json:"fail_fast,omitempty"`
+	MaxFailures    int        `json:"max_failures,omitempty"`
+	MaxFailureRate float64    `json:"max_failure_rate,omitempty"`
+	SuccessCodes   []int      `json:"success_codes,omitempty"`
+	ExpectStdout   string     `json:"expect_stdout,omitempty"`
+	RejectOutput   string     `json:"reject_output,omitempty"`
+	Warmup         int        `json:"warmup,omitempty"`
+	Setup          string     `json:"setup,omitempty"`
+	Teardown       string     `json:"teardown,omitempty"`
+	BeforeEach     string     `json:"before_each,omitempty"`
+	AfterEach      string     `json:"after_each,omitempty"`
+	Parser         string     `json:"parser,omitempty"`
+	ParseFile      string     `json:"parse_file,omitempty"`
+	ArtifactsDir   string     `json:"artifacts_dir,omitempty"`
+	Baseline       string     `json:"baseline,omitempty"`
+	Threshold      float64    `json:"threshold,omitempty"`
+}
+
+// HistorySummary holds the counts listed for a session without reading its runs.
+type HistorySummary struct {
+	Total     int     `json:"total"`
+	Executed  int     `json:"executed"`
+	Succeeded int     `json:"succeeded"`
+	Failed    int     `json:"failed"`
+	TimedOut  int     `json:"timed_out"`
+	Skipped   int     `json:"skipped"`
+	MeanMs    float64 `json:"mean_ms"`
+}
+
+// HistoryRun is one measured run of a recorded session, with the end of its output.
+type HistoryRun struct {
+	ID           int           `json:"id"`
+	CommandIndex int           `json:"command_index,omitempty"`
+	Command      []string      `json:"command"`
+	Status       string        `json:"status"`
+	Success      bool          `json:"success"`
+	Failure      string        `json:"failure,omitempty"`
+	ExitCode     int           `json:"exit_code"`
+	Signal       string        `json:"signal,omitempty"`
+	CoreDumped   bool          `json:"core_dumped,omitempty"`
+	Reason       string        `json:"reason,omitempty"`
+	Error        string        `json:"error,omitempty"`
+	StartedAt    time.Time     `json:"started_at"`
+	FinishedAt   time.Time     `json:"finished_at"`
+	DurationMs   float64       `json:"duration_ms"`
+	UserMs       float64       `json:"user_ms"`
+	SystemMs     float64       `json:"system_ms"`
+	MaxRSS       int64         `json:"max_rss_bytes"`
+	VoluntaryCtx int64         `json:"voluntary_ctx_switches"`
+	InvolCtx     int64         `json:"involuntary_ctx_switches"`
+	Hooks        []HistoryHook `json:"hooks,omitempty"`
+	Tests        []HistoryTest `json:"tests,omitempty"`
+	Signature    string        `json:"signature,omitempty"`
+	ArtifactDir  string        `json:"artifact_dir,omitempty"`
+	Stdout       string        `json:"stdout"`
+	Stderr       string        `json:"stderr"`
+}
+
+type HistoryHook struct {
+	Phase      string   `json:"phase"`
+	Command    []string `json:"command"`
+	ExitCode   int      `json:"exit_code"`
+	DurationMs float64  `json:"duration_ms"`
+	Success    bool     `json:"success"`
+	Failure    string   `json:"failure,omitempty"`
+	Reason     string   `json:"reason,omitempty"`
+}
+
+type HistoryTest struct {
+	Package    string  `json:"package,omitempty"`
+	Name       string  `json:"name"`
+	Status     string  `json:"status"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// historyLockTimeout bounds how long a session waits for another one to finish writing the history.
+const historyLockTimeout = 5 * time.Second
+
+var (
+	sessionsBucket = []byte("sessions") // Session ID to HistorySession
+	runsBucket     = []byte("runs")     // Session ID to a bucket of HistoryRun by run position
+)
+
+// HistoryStore keeps recorded sessions in an embedded bbolt database. Every session
+// has its runs in a bucket of its own, so listing sessions never reads their runs.
+// The database is only opened for the duration of each operation, which lets
+// concurrent sessions take turns recording.
+type HistoryStore struct {
+	path string
+}
+
+func NewHistoryStore(path string) *HistoryStore {
+	return &HistoryStore{path: path}
+}
+
+// OpenHistory returns the store at DefaultHistoryPath.
+func OpenHistory() (*HistoryStore, error) {
+	path, err := DefaultHistoryPath()
+	if err != nil {
+		return nil, err
+	}
+	return NewHistoryStore(path), nil
+}
+
+// DefaultHistoryPath returns again/history.db under the user's state directory:
+// $XDG_STATE_HOME, ~/.local/state on Unix, ~/Library/Application Support on
+// macOS and %LocalAppData% on Windows.
+func DefaultHistoryPath() (string, error) {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		var err error
+		switch runtime.GOOS {
+		case "windows":
+			dir, err = os.UserCacheDir()
+		case "darwin":
+			dir, err = os.UserConfigDir()
+		default:
+			dir, err = os.UserHomeDir()
+			dir = filepath.Join(dir, ".local", "state")
+		}
+		if err != nil {
+			return "", fmt.Errorf("locate history: %w", err)
+		}
+	}
+	return filepath.Join(dir, "again", "history.db"), nil
+}
+
+// Path returns the database file the store keeps its sessions in.
+func (s *HistoryStore) Path() string {
+	return s.path
+}
+
+// historyKey encodes IDs big-endian, so that keys sort in ID order.
+func historyKey(id int) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(id))
+}
+
+// update runs fn in a read-write transaction, creating the database and its buckets as needed.
+func (s *HistoryStore) update(fn func(sessions, runs *bolt.Bucket) error) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	db, err := bolt.Open(s.path, 0o600, &bolt.Options{Timeout: historyLockTimeout})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		sessions, err := tx.CreateBucketIfNotExists(sessionsBucket)
+		if err != nil {
+			return err
+		}
+		runs, err := tx.CreateBucketIfNotExists(runsBucket)
+		if err != nil {
+			return err
+		}
+		return fn(sessions, runs)
+	})
+}
+
+// view runs fn in a read-only transaction. fn is not called when nothing was recorded yet.
+func (s *HistoryStore) view(fn func(sessions, runs *bolt.Bucket) error) error {
+	db, err := bolt.Open(s.path, 0o600, &bolt.Options{Timeout: historyLockTimeout, ReadOnly: true})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.View(func(tx *bolt.Tx) error {
+		sessions, runs := tx.Bucket(sessionsBucket), tx.Bucket(runsBucket)
+		if sessions == nil || runs == nil {
+			return nil
+		}
+		return fn(sessions, runs)
+	})
+}
+
+// Save records session under the next free ID and returns that ID.
+func (s *HistoryStore) Save(session domain.Session) (int, error) {
+	err := s.update(func(sessions, runs *bolt.Bucket) error {
+		seq, err := sessions.NextSequence()
+		if err != nil {
+			return err
+		}
+		session.ID = int(seq)
+
+		header, err := encodeHistory(newHistorySession(session))
+		if err != nil {
+			return err
+		}
+		if err := sessions.Put(historyKey(session.ID), header); err != nil {
+			return err
+		}
+
+		bucket, err := runs.CreateBucket(historyKey(session.ID))
+		if err != nil {
+			return err
+		}
+		for i, result := range session.Results {
+			run, err := encodeHistory(newHistoryRun(result))
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put(historyKey(i+1), run); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("record history: %w", err)
+	}
+	return session.ID, nil
+}
+
+func encodeHistory(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	// Commands routinely contain shell redirections
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// List returns every recorded session without its runs, newest first. Sessions
+// that cannot be read, such as ones recorded by a newer version, are left out.
+func (s *HistoryStore) List() ([]domain.Session, error) {
+	var list []domain.Session
+	err := s.view(func(sessions, runs *bolt.Bucket) error {
+		c := sessions.Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			if session, err := decodeSession(v); err == nil {
+				list = append(list, session)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+	return list, nil
+}
+
+// Load reads a recorded session including its runs.
+func (s *HistoryStore) Load(id int) (domain.Session, error) {
+	var session domain.Session
+	found := false
+	err := s.view(func(sessions, runs *bolt.Bucket) error {
+		header := sessions.Get(historyKey(id))
+		if header == nil {
+			return nil
+		}
+		found = true
+
+		var err error
+		if session, err = decodeSession(header); err != nil {
+			return err
+		}
+
+		session.Results = []domain.RunResult{}
+		bucket := runs.Bucket(historyKey(id))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(_, v []byte) error {
+			var run HistoryRun
+			if err := json.Unmarshal(v, &run); err != nil {
+				return fmt.Errorf("session %d: invalid run: %w", id, err)
+			}
+			session.Results = append(session.Results, run.result())
+			return nil
+		})
+	})
+	if err != nil {
+		return domain.Session{}, fmt.Errorf("read history: %w", err)
+	}
+	if !found {
+		return domain.Session{}, fmt.Errorf("no session %d in history", id)
+	}
+
+	// Recompute the complete statistics the header only has counts for
+	session.Summary = domain.Summarize(session.Results)
+	return session, nil
+}
+
+func decodeSession(data []byte) (domain.Session, error) {
+	var header HistorySession
+	if err := json.Unmarshal(data, &header); err != nil {
+		return domain.Session{}, fmt.Errorf("invalid session: %w", err)
+	}
+	if header.Version != historyVersion {
+		return domain.Session{}, fmt.Errorf("session %d: unsupported session version %d", header.ID, header.Version)
+	}
+	return header.session(), nil
+}
+
+// sessionSizes is sizes within a transaction.
+func sessionSizes(sessions, runs *bolt.Bucket) (ids []int, sizes []int64) {
+	c := sessions.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		size := int64(len(k) + len(v))
+		if bucket := runs.Bucket(k); bucket != nil {
+			bucket.ForEach(func(k, v []byte) error {
+				size += int64(len(k) + len(v))
+				return nil
+			})
+		}
+		ids = append(ids, int(binary.BigEndian.Uint64(k)))
+		sizes = append(sizes, size)
+	}
+	return ids, sizes
+}
+
+// sizes returns the IDs of the recorded sessions in ascending order, with the bytes each one takes up.
+func (s *HistoryStore) sizes() (ids []int, sizes []int64, err error) {
+	err = s.view(func(sessions, runs *bolt.Bucket) error {
+		ids, sizes = sessionSizes(sessions, runs)
+		return nil
+	})
+	return ids, sizes, err
+}
+
+// Trim deletes the oldest sessions until at most keep remain and together they take up
+// no more than maxSize bytes; zero disables either limit. The size counts recorded data:
+// the database file keeps the pages of deleted sessions for the next ones instead of
+// shrinking. The most recent session is always kept. It returns the IDs of the deleted sessions.
+func (s *HistoryStore) Trim(keep int, maxSize int64) ([]int, error) {
+	var deleted []int
+	err := s.update(func(sessions, runs *bolt.Bucket) error {
+		ids, sizes := sessionSizes(sessions, runs)
+		var total int64
+		for _, size := range sizes {
+			total += size
+		}
+
+		for i, id := range ids[:max(0, len(ids)-1)] {
+			remaining := len(ids) - i
+			if (keep == 0 || remaining <= keep) && (maxSize == 0 || total <= maxSize) {
+				break
+			}
+			if err := deleteSession(sessions, runs, id); err != nil {
+				return err
+			}
+			total -= sizes[i]
+			deleted = append(deleted, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("trim history: %w", err)
+	}
+	return deleted, nil
+}
+
+// Delete removes a recorded session.
+func (s *HistoryStore) Delete(id int) error {
+	err := s.update(func(sessions, runs *bolt.Bucket) error {
+		if sessions.Get(historyKey(id)) == nil {
+			return errors.New("no such session")
+		}
+		return deleteSession(sessions, runs, id)
+	})
+	if err != nil {
+		return fmt.Errorf("delete session %d: %w", id, err)
+	}
+	return nil
+}
+
+func deleteSession(sessions, runs *bolt.Bucket, id int) error {
+	if err := sessions.Delete(historyKey(id)); err != nil {
+		return err
+	}
+	if runs.Bucket(historyKey(id)) == nil {
+		return nil
+	}
+	return runs.DeleteBucket(historyKey(id))
+}
+
+func newHistorySession(s domain.Session) HistorySession {
+	cfg := s.Config
+	return HistorySession{
+		Version:    historyVersion,
+		ID:         s.ID,
+		StartedAt:  s.StartedAt,
+		FinishedAt: s.FinishedAt,
+		Status:     string(s.Status),
+		Error:      s.Error,
+		Config: HistoryConfig{
+			Command:        cfg.Command,
+			Commands:       cfg.Commands,
+			Interleave:     cfg.Interleave,
+			Times:          cfg.Times,
+			Parallel:       cfg.Parallel,
+			Format:         string(cfg.Format),
+			TimeoutMs:      milliseconds(cfg.Timeout),
+			Until:          string(cfg.Until),
+			Streak:         cfg.Streak,
+			TimeBudgetMs:   milliseconds(cfg.TimeBudget),
+			FailFast:       cfg.FailFast,
+			MaxFailures:    cfg.MaxFailures,
+			MaxFailureRate: cfg.MaxFailureRate,
+			SuccessCodes:   cfg.SuccessCodes,
+			ExpectStdout:   cfg.ExpectStdout,
+			RejectOutput:   cfg.RejectOutput,
+			Warmup:         cfg.Warmup,
+			Setup:          cfg.Setup,
+			Teardown:       cfg.Teardown,
+			BeforeEach:     cfg.BeforeEach,
+			AfterEach:      cfg.AfterEach,
+			Parser:         string(cfg.Parser),
+			ParseFile:      cfg.ParseFile,
+			ArtifactsDir:   cfg.ArtifactsDir,
+			Baseline:       cfg.Baseline,
+			Threshold:      cfg.Threshold,
+		},
+		Summary: HistorySummary{
+			Total:     s.Summary.Total,
+			Executed:  s.Summary.Executed,
+			Succeeded: s.Summary.Succeeded,
+			Failed:    s.Summary.Failed,
+			TimedOut:  s.Summary.TimedOut,
+			Skipped:   s.Summary.Skipped,
+			MeanMs:    milliseconds(s.Summary.Durations.Mean),
+		},
+	}
+}
+
+func (h HistorySession) session() domain.Session {
+	c := h.Config
+	return domain.Session{
+		ID:         h.ID,
+		StartedAt:  h.StartedAt,
+		FinishedAt: h.FinishedAt,
+		Status:     domain.SessionStatus(h.Status),
+		Error:      h.Error,
+		Config: domain.RunConfig{
+			Command:        c.Command,
+			Commands:       c.Commands,
+			Interleave:     c.Interleave,
+			Times:          c.Times,
+			Parallel:       c.Parallel,
+			Format:         domain.OutputFormat(c.Format),
+			Timeout:        fromMilliseconds(c.TimeoutMs),
+			Until:          domain.UntilMode(c.Until),
+			Streak:         c.Streak,
+			TimeBudget:     fromMilliseconds(c.TimeBudgetMs),
+			FailFast:       c.FailFast,
+			MaxFailures:    c.MaxFailures,
+			MaxFailureRate: c.MaxFailureRate,
+			SuccessCodes:   c.SuccessCodes,
+			ExpectStdout:   c.ExpectStdout,
+			RejectOutput:   c.RejectOutput,
+			Warmup:         c.Warmup,
+			Setup:          c.Setup,
+			Teardown:       c.Teardown,
+			BeforeEach:     c.BeforeEach,
+			AfterEach:      c.AfterEach,
+			Parser:         domain.ParserKind(c.Parser),
+			ParseFile:      c.ParseFile,
+			ArtifactsDir:   c.ArtifactsDir,
+			Baseline:       c.Baseline,
+			Threshold:      c.Threshold,
+		},
+		Summary: domain.Summary{
+			Total:     h.Summary.Total,
+			Executed:  h.Summary.Executed,
+			Succeeded: h.Summary.Succeeded,
+			Failed:    h.Summary.Failed,
+			TimedOut:  h.Summary.TimedOut,
+			Skipped:   h.Summary.Skipped,
+			Durations: domain.DurationStats{Mean: fromMilliseconds(h.Summary.MeanMs)},
+		},
+	}
+}
+
+func newHistoryRun(r domain.RunResult) HistoryRun {
+	run := HistoryRun{
+		ID:           r.ID,
+		CommandIndex: r.CommandIndex,
+		Command:      r.Command,
+		Status:       string(r.Status),
+		Success:      r.Success,
+		Failure:      string(r.Failure),
+		ExitCode:     r.ExitCode,
+		Signal:       r.Signal,
+		CoreDumped:   r.CoreDumped,
+		Reason:       r.Reason,
+		StartedAt:    r.StartedAt,
+		FinishedAt:   r.FinishedAt,
+		DurationMs:   milliseconds(r.Duration),
+		UserMs:       milliseconds(r.Usage.UserTime),
+		SystemMs:     milliseconds(r.Usage.SystemTime),
+		MaxRSS:       r.Usage.MaxRSS,
+		VoluntaryCtx: r.Usage.VoluntaryCtxSwitches,
+		InvolCtx:     r.Usage.InvoluntaryCtxSwitches,
+		Signature:    r.Signature,
+		ArtifactDir:  r.ArtifactDir,
+		Stdout:       string(r.StdoutBytes()),
+		Stderr:       string(r.StderrBytes()),
+	}
+	if r.Error != nil {
+		run.Error = r.Error.Error()
+	}
+	for _, hook := range r.Hooks {
+		run.Hooks = append(run.Hooks, HistoryHook{
+			Phase:      string(hook.Phase),
+			Command:    hook.Command,
+			ExitCode:   hook.ExitCode,
+			DurationMs: milliseconds(hook.Duration),
+			Success:    hook.Success,
+			Failure:    string(hook.Failure),
+			Reason:     hook.Reason,
+		})
+	}
+	for _, test := range r.Tests {
+		run.Tests = append(run.Tests, HistoryTest{
+			Package:    test.Package,
+			Name:       test.Name,
+			Status:     string(test.Status),
+			DurationMs: milliseconds(test.Duration),
+		})
+	}
+	return run
+}
+
+func (h HistoryRun) result() domain.RunResult {
+	r := domain.RunResult{
+		ID:           h.ID,
+		CommandIndex: h.CommandIndex,
+		Command:      h.Command,
+		Status:       domain.RunStatus(h.Status),
+		Success:      h.Success,
+		Failure:      domain.FailureKind(h.Failure),
+		ExitCode:     h.ExitCode,
+		Signal:       h.Signal,
+		CoreDumped:   h.CoreDumped,
+		Reason:       h.Reason,
+		StartedAt:    h.StartedAt,
+		FinishedAt:   h.FinishedAt,
+		Duration:     fromMilliseconds(h.DurationMs),
+		Usage: domain.ResourceUsage{
+			UserTime:               fromMilliseconds(h.UserMs),
+			SystemTime:             fromMilliseconds(h.SystemMs),
+			MaxRSS:                 h.MaxRSS,
+			VoluntaryCtxSwitches:   h.VoluntaryCtx,
+			InvoluntaryCtxSwitches: h.InvolCtx,
+		},
+		Signature:   h.Signature,
+		ArtifactDir: h.ArtifactDir,
+		Stdout:      []byte(h.Stdout),
+		Stderr:      []byte(h.Stderr),
+	}
+	if h.Error != "" {
+		r.Error = errors.New(h.Error)
+	}
+	for _, hook := range h.Hooks {
+		r.Hooks = append(r.Hooks, domain.HookResult{
+			Phase:    domain.HookPhase(hook.Phase),
+			Command:  hook.Command,
+			ExitCode: hook.ExitCode,
+			Duration: fromMilliseconds(hook.DurationMs),
+			Success:  hook.Success,
+			Failure:  domain.FailureKind(hook.Failure),
+			Reason:   hook.Reason,
+		})
+	}
+	for _, test := range h.Tests {
+		r.Tests = append(r.Tests, domain.TestOutcome{
+			Package:  test.Package,
+			Name:     test.Name,
+			Status:   domain.TestStatus(test.Status),
+			Duration: fromMilliseconds(test.DurationMs),
+		})
+	}
+	return r
+}
+
+func fromMilliseconds(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
@@ -0,0 +1,136 @@
+package infra
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/msaeedsaeedi/again/internal/domain"
+)
+
+func TestHistoryStoreTrim(t *testing.T) {
+	tests := []struct {
+		name    string
+		keep    int
+		maxSize func(size int64) int64 // Given the size of one session
+		deleted []int
+	}{
+		{"no limits", 0, func(int64) int64 { return 0 }, nil},
+		{"under both limits", 5, func(size int64) int64 { return 5 * size }, nil},
+		{"keep", 2, func(int64) int64 { return 0 }, []int{1, 2}},
+		{"max size", 0, func(size int64) int64 { return 3 * size }, []int{1}},
+		{"stricter limit wins", 3, func(size int64) int64 { return size + 1 }, []int{1, 2, 3}},
+		{"the newest session is always kept", 0, func(int64) int64 { return 1 }, []int{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewHistoryStore(filepath.Join(t.TempDir(), "history.db"))
+			session := domain.Session{
+				Config:     domain.RunConfig{Command: []string{"true"}},
+				StartedAt:  time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+				FinishedAt: time.Date(2026, 1, 1, 0, 0, 1, 0, time.UTC),
+				Status:     domain.SessionPassed,
+			}
+			for range 4 {
+				if _, err := store.Save(session); err != nil {
+					t.Fatal(err)
+				}
+			}
+			_, sizes, err := store.sizes()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			deleted, err := store.Trim(tt.keep, tt.maxSize(sizes[0]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(deleted, tt.deleted) {
+				t.Errorf("Trim() deleted %v, want %v", deleted, tt.deleted)
+			}
+
+			ids, _, err := store.sizes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want []int
+			for id := 1; id <= 4; id++ {
+				if !slices.Contains(tt.deleted, id) {
+					want = append(want, id)
+				}
+			}
+			if !slices.Equal(ids, want) {
+				t.Errorf("sessions %v left, want %v", ids, want)
+			}
+		})
+	}
+}
+
+func TestHistoryStoreRoundTrip(t *testing.T) {
+	store := NewHistoryStore(filepath.Join(t.TempDir(), "history.db"))
+	started := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	session := domain.Session{
+		Config:     domain.RunConfig{Command: []string{"go", "test", "./..."}, Times: 2},
+		StartedAt:  started,
+		FinishedAt: started.Add(3 * time.Second),
+		Status:     domain.SessionFailed,
+		Results: []domain.RunResult{
+			{ID: 1, Status: domain.StatusSuccess, Success: true, Duration: time.Second, StartedAt: started, FinishedAt: started.Add(time.Second)},
+			{ID: 2, Status: domain.StatusFailed, Failure: domain.FailureExitNonZero, ExitCode: 1, Duration: 2 * time.Second,
+				StartedAt: started.Add(time.Second), FinishedAt: started.Add(3 * time.Second), Stderr: []byte("boom\n")},
+		},
+	}
+
+	id, err := store.Save(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := store.Load(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.ID != id || loaded.Status != session.Status || loaded.CommandLine() != "go test ./..." {
+		t.Errorf("loaded session %d %s %q, want %d %s %q", loaded.ID, loaded.Status, loaded.CommandLine(), id, session.Status, "go test ./...")
+	}
+	if !loaded.StartedAt.Equal(started) || loaded.Duration() != 3*time.Second {
+		t.Errorf("loaded timing %v for %v, want %v for 3s", loaded.StartedAt, loaded.Duration(), started)
+	}
+	if len(loaded.Results) != 2 || string(loaded.Results[1].Stderr) != "boom\n" || loaded.Results[1].ExitCode != 1 {
+		t.Errorf("loaded runs %+v", loaded.Results)
+	}
+	if loaded.Summary.Executed != 2 || loaded.Summary.Succeeded != 1 {
+		t.Errorf("loaded summary %+v, want 2 runs with 1 passed", loaded.Summary)
+	}
+
+	if _, err := store.Load(id + 1); err == nil {
+		t.Error("loading a missing session succeeded")
+	}
+	if err := store.Delete(id); err != nil {
+		t.Fatal(err)
+	}
+	if sessions, err := store.List(); err != nil || len(sessions) != 0 {
+		t.Errorf("List() after deleting the only session = %v, %v", sessions, err)
+	}
+}
+
+func TestHistoryStoreEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	store := NewHistoryStore(path)
+
+	if sessions, err := store.List(); err != nil || len(sessions) != 0 {
+		t.Errorf("List() = %v, %v, want no sessions", sessions, err)
+	}
+	if _, err := store.Load(1); err == nil {
+		t.Error("loading from an empty history succeeded")
+	}
+	// Reading never creates the database
+	if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("reading the history created %s: %v", path, err)
+	}
+}
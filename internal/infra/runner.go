@@ -1,11 +1,11 @@
 package infra
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
 	"runtime"
 	"time"
@@ -13,18 +13,39 @@ import (
 	"github.com/msaeedsaeedi/again/internal/domain"
 )
 
-type CommandRunner struct{}
+type CommandRunner struct {
+	// session prefixes spool file names, so that sessions sharing a spool directory never overwrite each other
+	session string
+}
 
 func NewCommandRunner() *CommandRunner {
-	return &CommandRunner{}
+	return &CommandRunner{
+		session: fmt.Sprintf("%s-%d", time.Now().Format("20060102-150405"), os.Getpid()),
+	}
+}
+
+// spoolName names the spool files of a run; warmups are numbered separately, so they get their own prefix.
+func (r *CommandRunner) spoolName(info domain.RunInfo) string {
+	kind := "run"
+	if info.Warmup {
+		kind = "warmup"
+	}
+	return fmt.Sprintf("%s-%s-%04d", r.session, kind, info.ID)
 }
 
-func (r *CommandRunner) Run(ctx context.Context, cfg *domain.RunConfig, runID int, stdoutWriter, stderrWriter io.Writer) domain.RunResult {
+func (r *CommandRunner) Run(ctx context.Context, cfg *domain.RunConfig, info domain.RunInfo, stdoutWriter, stderrWriter io.Writer) domain.RunResult {
 	result := domain.RunResult{
-		ID:        runID,
+		ID:        info.ID,
+		Command:   cfg.Command,
 		StartedAt: time.Now(),
 	}
 
+	command, err := cfg.ExpandCommand(cfg.Command, info)
+	if err != nil {
+		return startFailed(result, err)
+	}
+	result.Command = command
+
 	if cfg.Timeout > 0 {
 		var cancel context.CancelFunc
 		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
@@ -32,22 +53,31 @@ func (r *CommandRunner) Run(ctx context.Context, cfg *domain.RunConfig, runID in
 	}
 
 	var cmd *exec.Cmd
-	if len(cfg.Command) == 1 {
+	if len(command) == 1 {
 		if runtime.GOOS == "windows" {
-			cmd = exec.Command("cmd", "/C", cfg.Command[0])
+			cmd = exec.Command("cmd", "/C", command[0])
 		} else {
-			cmd = exec.Command("sh", "-c", cfg.Command[0])
+			cmd = exec.Command("sh", "-c", command[0])
 		}
 	} else {
-		cmd = exec.Command(cfg.Command[0], cfg.Command[1:]...)
+		cmd = exec.Command(command[0], command[1:]...)
 	}
 
+	cmd.Env = append(os.Environ(), info.Env()...)
 	setupProcessGroup(cmd)
 
-	// Limit output buffer size to prevent OOM (10 MB per stream)
-	const maxOutputSize = 10 * 1024 * 1024
-	stdout := &limitedBuffer{buf: &bytes.Buffer{}, limit: maxOutputSize}
-	stderr := &limitedBuffer{buf: &bytes.Buffer{}, limit: maxOutputSize}
+	// Limit retained output to prevent OOM, or spool it to disk
+	stdout, err := newOutputCapture(cfg, r.spoolName(info), "stdout")
+	if err != nil {
+		return startFailed(result, err)
+	}
+	defer stdout.Close()
+
+	stderr, err := newOutputCapture(cfg, r.spoolName(info), "stderr")
+	if err != nil {
+		return startFailed(result, err)
+	}
+	defer stderr.Close()
 
 	if stdoutWriter != nil {
 		cmd.Stdout = io.MultiWriter(stdout, stdoutWriter)
@@ -62,57 +92,58 @@ func (r *CommandRunner) Run(ctx context.Context, cfg *domain.RunConfig, runID in
 	}
 
 	if err := cmd.Start(); err != nil {
-		result.FinishedAt = time.Now()
-		result.Duration = result.FinishedAt.Sub(result.StartedAt)
 		result.Stdout = stdout.Bytes()
 		result.Stderr = stderr.Bytes()
-		result.Error = err
-		result.Success = false
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			result.ExitCode = exitErr.ExitCode()
-		} else {
-			result.ExitCode = -1
-		}
-		return result
+		return startFailed(result, err)
 	}
 
 	done := make(chan error, 1)
 	go func() { done <- cmd.Wait() }()
 
-	var err error
 	select {
 	case err = <-done:
 		// normal exit
 	case <-ctx.Done():
-		// Kill the process or process group
-		killProcess(cmd)
-		err = ctx.Err()
-		<-done
+		// A command that exited just as it was stopped keeps its own outcome
+		select {
+		case err = <-done:
+		default:
+			stopProcess(cmd, cfg.KillGrace, done)
+			err = ctx.Err()
+		}
 	}
 	result.FinishedAt = time.Now()
 	result.Duration = result.FinishedAt.Sub(result.StartedAt)
 	result.Stdout = stdout.Bytes()
 	result.Stderr = stderr.Bytes()
-
-	// Add warning if output was truncated
-	if stdout.truncated {
-		truncMsg := []byte("\n[OUTPUT TRUNCATED: exceeded 10MB limit]\n")
-		result.Stdout = append(result.Stdout, truncMsg...)
-	}
-	if stderr.truncated {
-		truncMsg := []byte("\n[OUTPUT TRUNCATED: exceeded 10MB limit]\n")
-		result.Stderr = append(result.Stderr, truncMsg...)
+	result.StdoutPath = stdout.Path()
+	result.StderrPath = stderr.Path()
+	if cmd.ProcessState != nil {
+		result.Usage = collectUsage(cmd.ProcessState)
 	}
 
 	if err != nil {
-		if errors.Is(ctx.Err(), context.Canceled) || errors.Is(err, context.Canceled) {
+		result.Success = false
+		result.Status = domain.StatusFailed
+		// Context errors only come from stopping the command, not from it exiting on its own
+		if errors.Is(err, context.Canceled) {
 			result.Error = errors.New("cancelled")
-		} else if errors.Is(ctx.Err(), context.DeadlineExceeded) || errors.Is(err, context.DeadlineExceeded) {
+			result.Failure = domain.FailureCancelled
+		} else if errors.Is(err, context.DeadlineExceeded) {
 			result.Error = fmt.Errorf("timeout: command exceeded %v", cfg.Timeout)
+			result.Status = domain.StatusTimeout
+			result.Failure = domain.FailureTimeout
 		} else {
 			result.Error = err
+			result.Failure = domain.FailureExitNonZero
+			if cmd.ProcessState != nil {
+				if signal, coreDumped, ok := exitSignal(cmd.ProcessState); ok {
+					result.Failure = domain.FailureSignaled
+					result.Signal = signal
+					result.CoreDumped = coreDumped
+				}
+			}
 		}
-		result.Success = false
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			result.ExitCode = exitErr.ExitCode()
 		} else {
@@ -120,34 +151,42 @@ func (r *CommandRunner) Run(ctx context.Context, cfg *domain.RunConfig, runID in
 		}
 	} else {
 		result.Success = true
+		result.Status = domain.StatusSuccess
 		result.ExitCode = 0
 	}
 
 	return result
 }
 
-type limitedBuffer struct {
-	buf       *bytes.Buffer
-	limit     int
-	truncated bool
+func startFailed(result domain.RunResult, err error) domain.RunResult {
+	result.FinishedAt = time.Now()
+	result.Duration = result.FinishedAt.Sub(result.StartedAt)
+	result.Error = err
+	result.Success = false
+	result.Status = domain.StatusFailed
+	result.Failure = domain.FailureStartError
+	result.ExitCode = -1
+	return result
 }
 
-func (lb *limitedBuffer) Write(p []byte) (n int, err error) {
-	if lb.buf.Len() >= lb.limit {
-		lb.truncated = true
-		return len(p), nil
+// stopProcess terminates the process group, escalating to a kill once grace expires,
+// and waits for the process to exit.
+func stopProcess(cmd *exec.Cmd, grace time.Duration, done <-chan error) {
+	if grace <= 0 {
+		killProcess(cmd)
+		<-done
+		return
 	}
 
-	available := lb.limit - lb.buf.Len()
-	if len(p) > available {
-		lb.buf.Write(p[:available])
-		lb.truncated = true
-		return len(p), nil
-	}
+	terminateProcess(cmd)
 
-	return lb.buf.Write(p)
-}
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
 
-func (lb *limitedBuffer) Bytes() []byte {
-	return lb.buf.Bytes()
+	select {
+	case <-done:
+	case <-timer.C:
+		killProcess(cmd)
+		<-done
+	}
 }
@@ -0,0 +1,50 @@
+package infra
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"testing"
+
+	"github.com/msaeedsaeedi/again/internal/domain"
+)
+
+func TestCommandRunnerTemplates(t *testing.T) {
+	if _, err := exec.LookPath("printf"); err != nil {
+		t.Skip("printf is not available")
+	}
+
+	tests := []struct {
+		name      string
+		command   []string
+		templates bool
+		want      string
+	}{
+		{
+			name:    "templates of the command itself reach it verbatim",
+			command: []string{"printf", "%s", "{{.State.Status}} {{.ID}}"},
+			want:    "{{.State.Status}} {{.ID}}",
+		},
+		{
+			name:      "templates expanded when enabled",
+			command:   []string{"printf", "%s", "run {{.ID}} of {{.Total}}"},
+			templates: true,
+			want:      "run 7 of 10",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &domain.RunConfig{Command: tt.command, Times: 10, Templates: tt.templates}
+			info := domain.RunInfo{ID: 7, Attempt: 7, Total: 10, Worker: 1}
+
+			result := NewCommandRunner().Run(context.Background(), cfg, info, io.Discard, io.Discard)
+			if result.Status != domain.StatusSuccess {
+				t.Fatalf("run failed: %+v", result)
+			}
+			if string(result.Stdout) != tt.want {
+				t.Errorf("stdout %q, want %q", result.Stdout, tt.want)
+			}
+		})
+	}
+}
@@ -3,8 +3,13 @@
 package infra
 
 import (
+	"os"
 	"os/exec"
+	"runtime"
 	"syscall"
+
+	"github.com/msaeedsaeedi/again/internal/domain"
+	"golang.org/x/sys/unix"
 )
 
 // setupProcessGroup sets up a process group for Unix systems
@@ -13,6 +18,13 @@ func setupProcessGroup(cmd *exec.Cmd) {
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 }
 
+// terminateProcess asks the process group to exit on Unix systems
+func terminateProcess(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+	}
+}
+
 // killProcess kills the process group on Unix systems
 func killProcess(cmd *exec.Cmd) {
 	if cmd.Process != nil {
@@ -21,3 +33,37 @@ func killProcess(cmd *exec.Cmd) {
 		_ = syscall.Kill(-pgid, syscall.SIGKILL)
 	}
 }
+
+// collectUsage reads rusage of the finished process on Unix systems
+func collectUsage(state *os.ProcessState) domain.ResourceUsage {
+	usage := domain.ResourceUsage{
+		UserTime:   state.UserTime(),
+		SystemTime: state.SystemTime(),
+	}
+
+	if rusage, ok := state.SysUsage().(*syscall.Rusage); ok {
+		// Linux reports max RSS in kilobytes, macOS in bytes
+		usage.MaxRSS = int64(rusage.Maxrss)
+		if runtime.GOOS != "darwin" {
+			usage.MaxRSS *= 1024
+		}
+		usage.VoluntaryCtxSwitches = int64(rusage.Nvcsw)
+		usage.InvoluntaryCtxSwitches = int64(rusage.Nivcsw)
+	}
+
+	return usage
+}
+
+// exitSignal reports the signal that terminated the process on Unix systems
+func exitSignal(state *os.ProcessState) (name string, coreDumped bool, ok bool) {
+	status, isWait := state.Sys().(syscall.WaitStatus)
+	if !isWait || !status.Signaled() {
+		return "", false, false
+	}
+
+	name = unix.SignalName(status.Signal())
+	if name == "" {
+		name = status.Signal().String()
+	}
+	return name, status.CoreDump(), true
+}
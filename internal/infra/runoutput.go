@@ -0,0 +1,125 @@
+package infra
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SavedRun is the captured output of one run, loaded from an artifact
+// directory or from json or ndjson results.
+type SavedRun struct {
+	Label  string // Where the run was loaded from, such as "results.json#3"
+	Stdout []byte
+	Stderr []byte
+}
+
+// savedResult holds the fields of a json result that locate its output.
+type savedResult struct {
+	Event      string `json:"event"`
+	ID         int    `json:"id"`
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+	StdoutPath string `json:"stdout_path"`
+	StderrPath string `json:"stderr_path"`
+}
+
+// LoadSavedRun loads a run referenced as an artifact directory such as
+// "artifacts/run-0003", or as a results file and run ID such as "results.json#3".
+func LoadSavedRun(ref string) (SavedRun, error) {
+	if info, err := os.Stat(ref); err == nil && info.IsDir() {
+		return loadArtifactRun(ref)
+	}
+
+	path, idText, ok := strings.Cut(ref, "#")
+	if !ok {
+		return SavedRun{}, fmt.Errorf("%s: expected an artifact directory or FILE#ID", ref)
+	}
+	id, err := strconv.Atoi(idText)
+	if err != nil {
+		return SavedRun{}, fmt.Errorf("%s: invalid run ID %q", ref, idText)
+	}
+
+	result, err := findSavedResult(path, id)
+	if err != nil {
+		return SavedRun{}, err
+	}
+
+	run := SavedRun{Label: ref, Stdout: []byte(result.Stdout), Stderr: []byte(result.Stderr)}
+	// Spooled output is referenced rather than embedded
+	if result.StdoutPath != "" || result.StderrPath != "" {
+		if run.Stdout, err = readOptional(result.StdoutPath); err != nil {
+			return SavedRun{}, err
+		}
+		if run.Stderr, err = readOptional(result.StderrPath); err != nil {
+			return SavedRun{}, err
+		}
+	}
+	return run, nil
+}
+
+func loadArtifactRun(dir string) (SavedRun, error) {
+	stdout, err := os.ReadFile(filepath.Join(dir, "stdout"))
+	if err != nil {
+		return SavedRun{}, fmt.Errorf("%s: not an artifact directory: %w", dir, err)
+	}
+	stderr, err := readOptional(filepath.Join(dir, "stderr"))
+	if err != nil {
+		return SavedRun{}, err
+	}
+	return SavedRun{Label: filepath.Clean(dir), Stdout: stdout, Stderr: stderr}, nil
+}
+
+// findSavedResult looks run id up in json output, or in the run_completed events of ndjson output.
+func findSavedResult(path string, id int) (savedResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return savedResult{}, err
+	}
+
+	var output struct {
+		Results []savedResult `json:"results"`
+	}
+	if err := json.Unmarshal(data, &output); err == nil {
+		for _, result := range output.Results {
+			if result.ID == id {
+				return result, nil
+			}
+		}
+		return savedResult{}, fmt.Errorf("%s: no run %d in results", path, id)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		var event savedResult
+		if json.Unmarshal(scanner.Bytes(), &event) != nil {
+			return savedResult{}, fmt.Errorf("%s: not json or ndjson results", path)
+		}
+		if event.Event == "run_completed" && event.ID == id {
+			return event, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return savedResult{}, err
+	}
+	return savedResult{}, fmt.Errorf("%s: no run %d in results", path, id)
+}
+
+// readOptional reads path, treating an empty path or a missing file as empty output.
+func readOptional(path string) ([]byte, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
@@ -0,0 +1,129 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/msaeedsaeedi/again/internal/domain"
+)
+
+// WriteSessions lists recorded sessions in a table, one row per session.
+func WriteSessions(w io.Writer, sessions []domain.Session) {
+	rows := [][]string{{"ID", "Started", "Took", "Runs", "Passed", "Failed", "Status", "Command"}}
+	for _, s := range sessions {
+		rows = append(rows, []string{
+			fmt.Sprint(s.ID),
+			s.StartedAt.Local().Format(time.DateTime),
+			roundDuration(s.Duration()).String(),
+			fmt.Sprint(s.Summary.Executed),
+			fmt.Sprint(s.Summary.Succeeded),
+			fmt.Sprint(s.Summary.Executed - s.Summary.Succeeded),
+			string(s.Status),
+			truncate(s.CommandLine(), 60),
+		})
+	}
+	for _, line := range formatTable(rows) {
+		fmt.Fprintln(w, line)
+	}
+}
+
+// WriteSession describes a recorded session: how it was run, its statistics,
+// comparison, tests and failures, and one line per run.
+func WriteSession(w io.Writer, s domain.Session) {
+	cfg := &s.Config
+
+	fmt.Fprintf(w, "[ Session %d ]\n", s.ID)
+	fmt.Fprintf(w, "  Command:     %s\n", s.CommandLine())
+	if options := sessionOptions(cfg); options != "" {
+		fmt.Fprintf(w, "  Options:     %s\n", options)
+	}
+	fmt.Fprintf(w, "  Started:     %s, took %v\n", s.StartedAt.Local().Format(time.DateTime), roundDuration(s.Duration()))
+	fmt.Fprintf(w, "  Status:      %s\n", s.Status)
+	if s.Error != "" {
+		fmt.Fprintf(w, "  Error:       %s\n", s.Error)
+	}
+
+	writeSummary(w, s.Summary)
+	if cfg.Comparing() {
+		writeComparison(w, domain.Compare(cfg, s.Results))
+	}
+	if cfg.Parser != domain.ParserNone {
+		writeTests(w, domain.SummarizeTests(s.Results))
+	}
+	if clusters := domain.ClusterFailures(s.Results); len(clusters) > 0 {
+		writeFailures(w, clusters)
+	}
+
+	fmt.Fprintln(w, "[ Runs ]")
+	for _, line := range formatTable(runsTable(s.Results)) {
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+}
+
+// runsTable lays out one row per run, starting with a header row.
+func runsTable(results []domain.RunResult) [][]string {
+	rows := [][]string{{"Run", "Status", "Exit", "Duration", "Failure"}}
+	for _, r := range results {
+		label := fmt.Sprint(r.ID)
+		if r.CommandIndex > 0 {
+			label = fmt.Sprintf("%d (command %d)", r.ID, r.CommandIndex)
+		}
+		if r.Status == domain.StatusSkipped {
+			rows = append(rows, []string{label, string(r.Status), "-", "-", ""})
+			continue
+		}
+		rows = append(rows, []string{label, string(r.Status), fmt.Sprint(r.ExitCode),
+			roundDuration(r.Duration).String(), r.FailureDescription()})
+	}
+	return rows
+}
+
+// sessionOptions renders the options that shaped a session the way they are given on the command line.
+func sessionOptions(cfg *domain.RunConfig) string {
+	var flags []string
+	add := func(format string, args ...any) {
+		flags = append(flags, fmt.Sprintf(format, args...))
+	}
+
+	switch {
+	case cfg.OpenEnded() && cfg.Times > 0:
+		add("--max %d", cfg.Times)
+	case !cfg.OpenEnded():
+		add("-n %d", cfg.Times)
+	}
+	if cfg.Parallel > 1 {
+		add("-p %d", cfg.Parallel)
+	}
+	switch cfg.Until {
+	case domain.UntilFail:
+		add("--until-fail")
+	case domain.UntilSuccess:
+		add("--until-success")
+	case domain.UntilStreak:
+		add("--until-streak %d", cfg.Streak)
+	}
+	if cfg.TimeBudget > 0 {
+		add("--for %v", cfg.TimeBudget)
+	}
+	if cfg.Timeout > 0 {
+		add("--timeout %v", cfg.Timeout)
+	}
+	if cfg.Warmup > 0 {
+		add("--warmup %d", cfg.Warmup)
+	}
+	if cfg.FailFast {
+		add("--fail-fast")
+	}
+	if cfg.MaxFailures > 0 {
+		add("--max-failures %d", cfg.MaxFailures)
+	}
+	if cfg.Parser != domain.ParserNone {
+		add("--parse %s", cfg.Parser)
+	}
+	if cfg.Interleave {
+		add("--interleave")
+	}
+	return strings.Join(flags, " ")
+}
@@ -5,24 +5,382 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/msaeedsaeedi/again/internal/domain"
 )
 
 type ResultJSON struct {
-	ID       int     `json:"id"`
-	ExitCode int     `json:"exit_code"`
-	Success  bool    `json:"success"`
-	Duration float64 `json:"duration_ms"`
-	Stdout   string  `json:"stdout,omitempty"`
-	Stderr   string  `json:"stderr,omitempty"`
-	Error    string  `json:"error,omitempty"`
+	ID           int             `json:"id"`
+	CommandIndex int             `json:"command_index,omitempty"` // Only when comparing commands
+	Command      []string        `json:"command,omitempty"`
+	ExitCode     int             `json:"exit_code"`
+	Success      bool            `json:"success"`
+	Status       string          `json:"status"`
+	Failure      string          `json:"failure,omitempty"`
+	Signal       string          `json:"signal,omitempty"`
+	CoreDump     bool            `json:"core_dumped,omitempty"`
+	Reason       string          `json:"reason,omitempty"`
+	Duration     float64         `json:"duration_ms"`
+	Stdout       string          `json:"stdout,omitempty"`
+	Stderr       string          `json:"stderr,omitempty"`
+	StdoutPath   string          `json:"stdout_path,omitempty"`
+	StderrPath   string          `json:"stderr_path,omitempty"`
+	Artifacts    string          `json:"artifact_dir,omitempty"`
+	Error        string          `json:"error,omitempty"`
+	Usage        *UsageJSON      `json:"usage,omitempty"`
+	Hooks        []HookJSON      `json:"hooks,omitempty"`
+	Tests        *TestCountsJSON `json:"tests,omitempty"`             // Only with --parse
+	Signature    string          `json:"failure_signature,omitempty"` // ID of the failure cluster
+}
+
+// TestCountsJSON tallies the test cases parsed from one run's output.
+type TestCountsJSON struct {
+	Passed      int      `json:"passed"`
+	Failed      int      `json:"failed"`
+	Skipped     int      `json:"skipped"`
+	FailedTests []string `json:"failed_tests,omitempty"`
+}
+
+// TestStatsJSON aggregates one test case across runs.
+type TestStatsJSON struct {
+	Package     string  `json:"package,omitempty"`
+	Name        string  `json:"name"`
+	Runs        int     `json:"runs"`
+	Passed      int     `json:"passed"`
+	Failed      int     `json:"failed"`
+	Skipped     int     `json:"skipped"`
+	FailureRate float64 `json:"failure_rate"`
+	Flaky       bool    `json:"flaky"`
+}
+
+type HookJSON struct {
+	Phase    string   `json:"phase"`
+	Command  []string `json:"command"`
+	ExitCode int      `json:"exit_code"`
+	Success  bool     `json:"success"`
+	Failure  string   `json:"failure,omitempty"`
+	Reason   string   `json:"reason,omitempty"`
+	Duration float64  `json:"duration_ms"`
+	Stderr   string   `json:"stderr,omitempty"` // Only kept for failed hooks
+}
+
+type UsageJSON struct {
+	UserTime               float64 `json:"user_ms"`
+	SystemTime             float64 `json:"system_ms"`
+	MaxRSS                 int64   `json:"max_rss_bytes"`
+	VoluntaryCtxSwitches   int64   `json:"voluntary_ctx_switches"`
+	InvoluntaryCtxSwitches int64   `json:"involuntary_ctx_switches"`
+}
+
+type ResourceStatsJSON struct {
+	UserTime   DurationStatsJSON `json:"user_cpu"`
+	SystemTime DurationStatsJSON `json:"system_cpu"`
+	MaxRSSMean int64             `json:"max_rss_mean_bytes"`
+	MaxRSSPeak int64             `json:"max_rss_peak_bytes"`
+}
+
+type DurationStatsJSON struct {
+	Mean   float64 `json:"mean_ms"`
+	Min    float64 `json:"min_ms"`
+	Max    float64 `json:"max_ms"`
+	StdDev float64 `json:"stddev_ms"`
+	P50    float64 `json:"p50_ms"`
+	P90    float64 `json:"p90_ms"`
+	P95    float64 `json:"p95_ms"`
+	P99    float64 `json:"p99_ms"`
+}
+
+type SummaryJSON struct {
+	Total       int               `json:"total"`
+	Executed    int               `json:"executed"`
+	Succeeded   int               `json:"succeeded"`
+	Failed      int               `json:"failed"`
+	TimedOut    int               `json:"timed_out"`
+	Skipped     int               `json:"skipped"`
+	HookFailed  int               `json:"hook_failures"`
+	SuccessRate float64           `json:"success_rate"`
+	WallTime    float64           `json:"wall_time_ms"`
+	Throughput  float64           `json:"throughput_per_sec"`
+	Duration    DurationStatsJSON `json:"duration"`
+	Resources   ResourceStatsJSON `json:"resources"`
+}
+
+// CommandComparisonJSON summarizes one compared command; relative values are against the fastest.
+type CommandComparisonJSON struct {
+	Index          int         `json:"index"`
+	Command        []string    `json:"command"`
+	MeanCILow      float64     `json:"mean_ci_low_ms"`
+	MeanCIHigh     float64     `json:"mean_ci_high_ms"`
+	Relative       float64     `json:"relative"`
+	RelativeStdDev float64     `json:"relative_stddev"`
+	Summary        SummaryJSON `json:"summary"`
+}
+
+type ComparisonJSON struct {
+	ConfidenceLevel float64                 `json:"confidence_level"`
+	Interleaved     bool                    `json:"interleaved"`
+	Fastest         int                     `json:"fastest"` // Index of the fastest command, 0 when nothing was measured
+	Commands        []CommandComparisonJSON `json:"commands"`
+}
+
+// BaselineJSON is the verdict of testing the runs against a --compare baseline.
+type BaselineJSON struct {
+	Path                string    `json:"path"`
+	Command             []string  `json:"command"`
+	CreatedAt           time.Time `json:"created_at"`
+	Verdict             string    `json:"verdict"`
+	PValue              float64   `json:"p_value"`
+	Significance        float64   `json:"significance"`
+	Threshold           float64   `json:"threshold_pct"`
+	Change              float64   `json:"change_pct"`
+	Mean                float64   `json:"mean_ms"`
+	BaselineMean        float64   `json:"baseline_mean_ms"`
+	Runs                int       `json:"runs"`
+	BaselineRuns        int       `json:"baseline_runs"`
+	SuccessRate         float64   `json:"success_rate"`
+	BaselineSuccessRate float64   `json:"baseline_success_rate"`
+}
+
+// FailureClusterJSON groups failed runs whose normalized output tails match.
+type FailureClusterJSON struct {
+	ID        string   `json:"id"`
+	Failure   string   `json:"failure"`
+	Count     int      `json:"count"`
+	Share     float64  `json:"share"` // Percentage of failed runs in the cluster
+	Example   int      `json:"example_run"`
+	Runs      []int    `json:"runs"`
+	Signature []string `json:"signature"`
+	Output    []string `json:"example_output,omitempty"`
+}
+
+type OutputJSON struct {
+	Hooks      []HookJSON      `json:"hooks,omitempty"`  // Setup and teardown
+	Warmup     []ResultJSON    `json:"warmup,omitempty"` // Only with --include-warmup
+	Results    []ResultJSON    `json:"results"`
+	Summary    SummaryJSON     `json:"summary"`
+	Comparison *ComparisonJSON `json:"comparison,omitempty"` // Only when comparing commands
+	Baseline   *BaselineJSON   `json:"baseline,omitempty"`   // Only with --compare
+	Tests      []TestStatsJSON `json:"tests,omitempty"`      // Only with --parse, most frequently failing first
+	// FailureClusters groups failed runs by failure mode, largest first
+	FailureClusters []FailureClusterJSON `json:"failure_clusters,omitempty"`
+}
+
+func newResultJSON(res domain.RunResult) ResultJSON {
+	resultJSON := ResultJSON{
+		ID:           res.ID,
+		CommandIndex: res.CommandIndex,
+		Command:      res.Command,
+		ExitCode:     res.ExitCode,
+		Success:      res.Success,
+		Status:       string(res.Status),
+		Failure:      string(res.Failure),
+		Signal:       res.Signal,
+		CoreDump:     res.CoreDumped,
+		Reason:       res.Reason,
+		Duration:     durationMs(res.Duration),
+		Stdout:       string(res.Stdout),
+		Stderr:       string(res.Stderr),
+		StdoutPath:   res.StdoutPath,
+		StderrPath:   res.StderrPath,
+		Artifacts:    res.ArtifactDir,
+	}
+	if res.Error != nil {
+		resultJSON.Error = res.Error.Error()
+	}
+	if res.Usage != (domain.ResourceUsage{}) {
+		resultJSON.Usage = &UsageJSON{
+			UserTime:               durationMs(res.Usage.UserTime),
+			SystemTime:             durationMs(res.Usage.SystemTime),
+			MaxRSS:                 res.Usage.MaxRSS,
+			VoluntaryCtxSwitches:   res.Usage.VoluntaryCtxSwitches,
+			InvoluntaryCtxSwitches: res.Usage.InvoluntaryCtxSwitches,
+		}
+	}
+	for _, hook := range res.Hooks {
+		resultJSON.Hooks = append(resultJSON.Hooks, newHookJSON(hook))
+	}
+	if res.Signature != "" {
+		resultJSON.Signature = domain.SignatureID(res.Signature)
+	}
+	if len(res.Tests) > 0 {
+		counts := res.TestCounts()
+		resultJSON.Tests = &TestCountsJSON{Passed: counts.Passed, Failed: counts.Failed, Skipped: counts.Skipped}
+		for _, test := range res.FailedTests() {
+			resultJSON.Tests.FailedTests = append(resultJSON.Tests.FailedTests, testName(test.Package, test.Name))
+		}
+	}
+	return resultJSON
+}
+
+// newResultsJSON converts results ordered by ID, since parallel runs complete out of order.
+func newResultsJSON(results []domain.RunResult) []ResultJSON {
+	sort.Slice(results, func(i, j int) bool { return results[i].ID < results[j].ID })
+
+	out := make([]ResultJSON, 0, len(results))
+	for _, res := range results {
+		out = append(out, newResultJSON(res))
+	}
+	return out
+}
+
+func newHookJSON(hook domain.HookResult) HookJSON {
+	hookJSON := HookJSON{
+		Phase:    string(hook.Phase),
+		Command:  hook.Command,
+		ExitCode: hook.ExitCode,
+		Success:  hook.Success,
+		Failure:  string(hook.Failure),
+		Reason:   hook.Reason,
+		Duration: durationMs(hook.Duration),
+	}
+	if !hook.Success {
+		hookJSON.Stderr = string(hook.Stderr)
+	}
+	return hookJSON
+}
+
+func newDurationStatsJSON(d domain.DurationStats) DurationStatsJSON {
+	return DurationStatsJSON{
+		Mean:   durationMs(d.Mean),
+		Min:    durationMs(d.Min),
+		Max:    durationMs(d.Max),
+		StdDev: durationMs(d.StdDev),
+		P50:    durationMs(d.P50),
+		P90:    durationMs(d.P90),
+		P95:    durationMs(d.P95),
+		P99:    durationMs(d.P99),
+	}
+}
+
+func newSummaryJSON(s domain.Summary) SummaryJSON {
+	return SummaryJSON{
+		Total:       s.Total,
+		Executed:    s.Executed,
+		Succeeded:   s.Succeeded,
+		Failed:      s.Failed,
+		TimedOut:    s.TimedOut,
+		Skipped:     s.Skipped,
+		HookFailed:  s.HookFailed,
+		SuccessRate: s.SuccessRate,
+		WallTime:    durationMs(s.WallTime),
+		Throughput:  s.Throughput,
+		Duration:    newDurationStatsJSON(s.Durations),
+		Resources: ResourceStatsJSON{
+			UserTime:   newDurationStatsJSON(s.Resources.UserTime),
+			SystemTime: newDurationStatsJSON(s.Resources.SystemTime),
+			MaxRSSMean: s.Resources.MaxRSSMean,
+			MaxRSSPeak: s.Resources.MaxRSSPeak,
+		},
+	}
+}
+
+// newTestStatsJSON returns nil when no test results were parsed.
+func newTestStatsJSON(stats []domain.TestStats) []TestStatsJSON {
+	if len(stats) == 0 {
+		return nil
+	}
+
+	out := make([]TestStatsJSON, 0, len(stats))
+	for _, s := range stats {
+		out = append(out, TestStatsJSON{
+			Package:     s.Package,
+			Name:        s.Name,
+			Runs:        s.Runs,
+			Passed:      s.Passed,
+			Failed:      s.Failed,
+			Skipped:     s.Skipped,
+			FailureRate: s.FailureRate,
+			Flaky:       s.Flaky(),
+		})
+	}
+	return out
+}
+
+// newFailureClustersJSON returns nil when no run failed.
+func newFailureClustersJSON(clusters []domain.FailureCluster) []FailureClusterJSON {
+	if len(clusters) == 0 {
+		return nil
+	}
+
+	failed := failedRuns(clusters)
+	out := make([]FailureClusterJSON, 0, len(clusters))
+	for _, c := range clusters {
+		runs := slices.Clone(c.Runs)
+		slices.Sort(runs)
+		out = append(out, FailureClusterJSON{
+			ID:        c.ID,
+			Failure:   string(c.Failure),
+			Count:     c.Count(),
+			Share:     float64(c.Count()) * 100 / float64(failed),
+			Example:   c.Example,
+			Runs:      runs,
+			Signature: strings.Split(c.Signature, "\n"),
+			Output:    c.Output,
+		})
+	}
+	return out
+}
+
+// newComparisonJSON returns nil unless cfg compares several commands.
+func newComparisonJSON(cfg *domain.RunConfig, cmp domain.Comparison) *ComparisonJSON {
+	if !cfg.Comparing() {
+		return nil
+	}
+
+	out := &ComparisonJSON{
+		ConfidenceLevel: domain.ConfidenceLevel,
+		Interleaved:     cfg.Interleave,
+		Fastest:         cmp.Fastest,
+		Commands:        make([]CommandComparisonJSON, 0, len(cmp.Commands)),
+	}
+	for _, c := range cmp.Commands {
+		out.Commands = append(out.Commands, CommandComparisonJSON{
+			Index:          c.Index,
+			Command:        c.Command,
+			MeanCILow:      durationMs(c.MeanCI.Low),
+			MeanCIHigh:     durationMs(c.MeanCI.High),
+			Relative:       c.Relative,
+			RelativeStdDev: c.RelativeStdDev,
+			Summary:        newSummaryJSON(c.Summary),
+		})
+	}
+	return out
+}
+
+// newBaselineJSON returns nil when no baseline was checked.
+func newBaselineJSON(cfg *domain.RunConfig, check *domain.BaselineCheck) *BaselineJSON {
+	if check == nil {
+		return nil
+	}
+	return &BaselineJSON{
+		Path:                cfg.Baseline,
+		Command:             check.Baseline.Command,
+		CreatedAt:           check.Baseline.CreatedAt,
+		Verdict:             string(check.Verdict),
+		PValue:              check.PValue,
+		Significance:        domain.Significance,
+		Threshold:           check.Threshold,
+		Change:              check.Change,
+		Mean:                durationMs(check.Current.Mean),
+		BaselineMean:        durationMs(check.Previous.Mean),
+		Runs:                check.Current.Count,
+		BaselineRuns:        check.Previous.Count,
+		SuccessRate:         check.SuccessRate,
+		BaselineSuccessRate: check.Baseline.SuccessRate,
+	}
 }
 
 type JSONFormatter struct {
 	config  *domain.RunConfig
 	results []domain.RunResult
+	hooks   []domain.HookResult // Setup and teardown
+	warmups []domain.RunResult
+	check   *domain.BaselineCheck
 	mu      sync.Mutex
 }
 
@@ -33,7 +391,7 @@ func NewJSONFormatter(cfg *domain.RunConfig) *JSONFormatter {
 	}
 }
 
-func (f *JSONFormatter) GetOutputWriters() (stdout, stderr io.Writer) {
+func (f *JSONFormatter) GetOutputWriters(runID int) (stdout, stderr io.Writer) {
 	return nil, nil
 }
 
@@ -47,30 +405,68 @@ func (f *JSONFormatter) OnComplete(result domain.RunResult) {
 	f.results = append(f.results, result)
 }
 
+func (f *JSONFormatter) OnHookStart(runID int, phase domain.HookPhase) {}
+
+func (f *JSONFormatter) OnHookComplete(runID int, result domain.HookResult) {
+	// Per-run hooks are reported with their run
+	if runID != 0 {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.hooks = append(f.hooks, result)
+}
+
+func (f *JSONFormatter) OnWarmupStart(n int) {}
+
+func (f *JSONFormatter) OnWarmupComplete(result domain.RunResult) {
+	if !f.config.IncludeWarmup {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.warmups = append(f.warmups, result)
+}
+
+func (f *JSONFormatter) GetWarmupWriters(n int) (stdout, stderr io.Writer) {
+	return nil, nil
+}
+
+func (f *JSONFormatter) OnBaselineCheck(check domain.BaselineCheck) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.check = &check
+}
+
 func (f *JSONFormatter) OnFinish() {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
-	results := make([]ResultJSON, 0, len(f.results))
+	results := newResultsJSON(f.results)
 
-	for _, res := range f.results {
-		resultJSON := ResultJSON{
-			ID:       res.ID,
-			ExitCode: res.ExitCode,
-			Success:  res.Success,
-			Duration: float64(res.Duration.Milliseconds()),
-			Stdout:   string(res.Stdout),
-			Stderr:   string(res.Stderr),
-		}
-		if res.Error != nil {
-			resultJSON.Error = res.Error.Error()
-		}
-		results = append(results, resultJSON)
+	var warmup []ResultJSON
+	if len(f.warmups) > 0 {
+		warmup = newResultsJSON(f.warmups)
 	}
 
 	encoder := json.NewEncoder(os.Stdout)
 	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(map[string][]ResultJSON{"results": results}); err != nil {
+	var hooks []HookJSON
+	for _, hook := range f.hooks {
+		hooks = append(hooks, newHookJSON(hook))
+	}
+
+	output := OutputJSON{
+		Hooks:           hooks,
+		Warmup:          warmup,
+		Results:         results,
+		Summary:         newSummaryJSON(domain.Summarize(f.results)),
+		Comparison:      newComparisonJSON(f.config, domain.Compare(f.config, f.results)),
+		Baseline:        newBaselineJSON(f.config, f.check),
+		Tests:           newTestStatsJSON(domain.SummarizeTests(f.results)),
+		FailureClusters: newFailureClustersJSON(domain.ClusterFailures(f.results)),
+	}
+	if err := encoder.Encode(output); err != nil {
 		fmt.Fprintf(os.Stderr, "Error encoding JSON output: %v\n", err)
 		os.Exit(1)
 	}
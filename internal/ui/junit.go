@@ -0,0 +1,393 @@
+package ui
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/msaeedsaeedi/again/internal/domain"
+)
+
+// Only the tail of stderr is kept, which is where failures usually explain themselves
+const junitMaxStderr = 4 * 1024
+
+type JUnitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+type JUnitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr,omitempty"`
+	Properties []JUnitProperty `xml:"properties>property,omitempty"`
+	TestCases  []JUnitTestCase `xml:"testcase"`
+}
+
+type JUnitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+	Skipped   *JUnitSkipped `xml:"skipped,omitempty"`
+}
+
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",cdata"`
+}
+
+type JUnitSkipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// JUnitFormatter writes a JUnit XML report where every run is a test case.
+type JUnitFormatter struct {
+	config  *domain.RunConfig
+	cases   map[int]JUnitTestCase
+	warmups map[int]JUnitTestCase // Only with --include-warmup
+	stats   *domain.StatsCollector
+	wstats  *domain.StatsCollector
+	compare *domain.ComparisonCollector
+	tests   *domain.TestCollector
+	faults  *domain.FailureClusterer
+	check   *domain.BaselineCheck
+	start   time.Time
+	mu      sync.Mutex
+}
+
+func NewJUnitFormatter(cfg *domain.RunConfig) *JUnitFormatter {
+	return &JUnitFormatter{
+		config:  cfg,
+		cases:   make(map[int]JUnitTestCase),
+		warmups: make(map[int]JUnitTestCase),
+		stats:   domain.NewStatsCollector(),
+		wstats:  domain.NewStatsCollector(),
+		compare: domain.NewComparisonCollector(cfg),
+		tests:   domain.NewTestCollector(),
+		faults:  domain.NewFailureClusterer(),
+	}
+}
+
+func (f *JUnitFormatter) GetOutputWriters(runID int) (stdout, stderr io.Writer) {
+	return nil, nil
+}
+
+func (f *JUnitFormatter) OnStart(runID int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.start.IsZero() {
+		f.start = time.Now()
+	}
+}
+
+func (f *JUnitFormatter) OnComplete(result domain.RunResult) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.stats.Add(result)
+	f.compare.Add(result)
+	f.tests.Add(result)
+	f.faults.Add(result)
+	f.cases[result.ID] = newJUnitTestCase(f.config, result)
+}
+
+func (f *JUnitFormatter) OnWarmupStart(n int) {}
+
+func (f *JUnitFormatter) OnWarmupComplete(result domain.RunResult) {
+	if !f.config.IncludeWarmup {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.wstats.Add(result)
+	f.warmups[result.ID] = newJUnitTestCase(f.config, result)
+}
+
+func (f *JUnitFormatter) GetWarmupWriters(n int) (stdout, stderr io.Writer) {
+	return nil, nil
+}
+
+func (f *JUnitFormatter) OnFinish() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	summary := f.stats.Summary()
+	name := strings.Join(f.config.Command, " ")
+	var suites []JUnitTestSuite
+	if f.config.Comparing() {
+		name = "comparison"
+		suites = f.commandSuites()
+	} else {
+		properties := append(junitSummaryProperties(summary), junitBaselineProperties(f.check)...)
+		properties = append(properties, JUnitProperty{Name: "failure_clusters", Value: fmt.Sprint(len(f.faults.Clusters()))})
+		suites = []JUnitTestSuite{f.newSuite(name, summary, properties, f.cases)}
+	}
+
+	report := JUnitTestSuites{
+		Name:     "again",
+		Tests:    summary.Total,
+		Failures: summary.Failed + summary.TimedOut,
+		Skipped:  summary.Skipped,
+		Time:     junitSeconds(summary.WallTime),
+		Suites:   suites,
+	}
+
+	if f.config.Parser != domain.ParserNone {
+		suite := newTestsSuite(name+" (tests)", f.tests.Stats())
+		report.Suites = append(report.Suites, suite)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+	}
+
+	// Warmups get their own suite so they never mix with the measured runs
+	if len(f.warmups) > 0 {
+		warmup := f.wstats.Summary()
+		report.Suites = append(report.Suites, JUnitTestSuite{
+			Name:      name + " (warmup)",
+			Tests:     warmup.Total,
+			Failures:  warmup.Failed + warmup.TimedOut,
+			Skipped:   warmup.Skipped,
+			Time:      junitSeconds(warmup.WallTime),
+			TestCases: sortedJUnitCases(f.warmups),
+		})
+		report.Tests += warmup.Total
+		report.Failures += warmup.Failed + warmup.TimedOut
+		report.Skipped += warmup.Skipped
+	}
+
+	io.WriteString(os.Stdout, xml.Header)
+	encoder := xml.NewEncoder(os.Stdout)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding JUnit output: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintln(os.Stdout)
+}
+
+func (f *JUnitFormatter) OnBaselineCheck(check domain.BaselineCheck) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.check = &check
+}
+
+func (f *JUnitFormatter) newSuite(name string, summary domain.Summary, properties []JUnitProperty, cases map[int]JUnitTestCase) JUnitTestSuite {
+	suite := JUnitTestSuite{
+		Name:       name,
+		Tests:      summary.Total,
+		Failures:   summary.Failed + summary.TimedOut,
+		Skipped:    summary.Skipped,
+		Time:       junitSeconds(summary.WallTime),
+		Properties: properties,
+		TestCases:  sortedJUnitCases(cases),
+	}
+	if !f.start.IsZero() {
+		suite.Timestamp = f.start.Format(time.RFC3339)
+	}
+	return suite
+}
+
+// commandSuites reports each compared command as its own suite, with its speed relative to the fastest.
+func (f *JUnitFormatter) commandSuites() []JUnitTestSuite {
+	cmp := f.compare.Comparison()
+	suites := make([]JUnitTestSuite, 0, len(cmp.Commands))
+	for _, c := range cmp.Commands {
+		cases := make(map[int]JUnitTestCase)
+		for id, tc := range f.cases {
+			if f.config.CommandIndex(id) == c.Index {
+				cases[id] = tc
+			}
+		}
+
+		properties := append(junitSummaryProperties(c.Summary),
+			JUnitProperty{Name: "duration_mean_ci_low_ms", Value: fmt.Sprintf("%.3f", durationMs(c.MeanCI.Low))},
+			JUnitProperty{Name: "duration_mean_ci_high_ms", Value: fmt.Sprintf("%.3f", durationMs(c.MeanCI.High))},
+			JUnitProperty{Name: "relative", Value: fmt.Sprintf("%.3f", c.Relative)},
+			JUnitProperty{Name: "relative_stddev", Value: fmt.Sprintf("%.3f", c.RelativeStdDev)},
+			JUnitProperty{Name: "fastest", Value: fmt.Sprint(c.Index == cmp.Fastest)},
+		)
+		suites = append(suites, f.newSuite(strings.Join(c.Command, " "), c.Summary, properties, cases))
+	}
+	return suites
+}
+
+// newTestsSuite reports every parsed test as one case, failed when it failed in any run.
+func newTestsSuite(name string, stats []domain.TestStats) JUnitTestSuite {
+	suite := JUnitTestSuite{Name: name, Tests: len(stats), Time: junitSeconds(0)}
+	for _, s := range stats {
+		tc := JUnitTestCase{Name: s.Name, ClassName: s.Package, Time: junitSeconds(0)}
+		switch {
+		case s.Failed > 0:
+			tc.Failure = &JUnitFailure{
+				Message: fmt.Sprintf("failed %d of %d runs (%.1f%%)", s.Failed, s.Runs, s.FailureRate),
+				Type:    "flaky",
+				Body:    fmt.Sprintf("Passed: %d\nFailed: %d\nSkipped: %d\n", s.Passed, s.Failed, s.Skipped),
+			}
+			if !s.Flaky() {
+				tc.Failure.Type = "failure"
+			}
+			suite.Failures++
+		case s.Skipped == s.Runs:
+			tc.Skipped = &JUnitSkipped{Message: fmt.Sprintf("skipped in all %d runs", s.Runs)}
+			suite.Skipped++
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+	return suite
+}
+
+func sortedJUnitCases(cases map[int]JUnitTestCase) []JUnitTestCase {
+	ids := make([]int, 0, len(cases))
+	for id := range cases {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	sorted := make([]JUnitTestCase, 0, len(ids))
+	for _, id := range ids {
+		sorted = append(sorted, cases[id])
+	}
+	return sorted
+}
+
+func newJUnitTestCase(cfg *domain.RunConfig, result domain.RunResult) JUnitTestCase {
+	name := fmt.Sprintf("run-%04d", result.ID)
+	if result.Warmup {
+		name = fmt.Sprintf("warmup-%04d", result.ID)
+	}
+
+	tc := JUnitTestCase{
+		Name:      name,
+		ClassName: "again",
+		Time:      junitSeconds(result.Duration),
+	}
+
+	switch result.Status {
+	case domain.StatusSuccess:
+	case domain.StatusSkipped:
+		tc.Skipped = &JUnitSkipped{Message: "execution stopped early"}
+	case domain.StatusTimeout:
+		tc.Failure = &JUnitFailure{
+			Message: fmt.Sprintf("timed out after %v", cfg.Timeout),
+			Type:    string(domain.FailureTimeout),
+			Body:    junitFailureBody(result),
+		}
+	default:
+		failureType := string(result.Failure)
+		if failureType == "" {
+			failureType = "failure"
+		}
+		tc.Failure = &JUnitFailure{
+			Message: result.FailureDescription(),
+			Type:    failureType,
+			Body:    junitFailureBody(result),
+		}
+	}
+
+	return tc
+}
+
+func junitFailureBody(result domain.RunResult) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Exit code: %d\n", result.ExitCode)
+	if result.Signal != "" {
+		fmt.Fprintf(&sb, "Signal: %s (core dumped: %t)\n", result.Signal, result.CoreDumped)
+	}
+	if result.Error != nil {
+		fmt.Fprintf(&sb, "Error: %v\n", result.Error)
+	}
+	for _, hook := range result.Hooks {
+		if !hook.Success {
+			fmt.Fprintf(&sb, "Hook %s failed: %s\n", hook.Phase, hook.Reason)
+		}
+	}
+	if result.ArtifactDir != "" {
+		fmt.Fprintf(&sb, "Artifacts: %s\n", result.ArtifactDir)
+	}
+	if result.Signature != "" {
+		fmt.Fprintf(&sb, "Failure signature: %s\n", domain.SignatureID(result.Signature))
+	}
+	for _, test := range result.FailedTests() {
+		fmt.Fprintf(&sb, "Failed test: %s\n", testName(test.Package, test.Name))
+	}
+
+	stderr := result.StderrTail(junitMaxStderr)
+	if len(stderr) == junitMaxStderr {
+		fmt.Fprintf(&sb, "Stderr (last %d bytes):\n", junitMaxStderr)
+	} else if len(stderr) > 0 {
+		sb.WriteString("Stderr:\n")
+	}
+	sb.Write(stderr)
+
+	return junitSanitize(sb.String())
+}
+
+// junitSanitize drops characters that are not allowed in XML documents.
+func junitSanitize(s string) string {
+	s = strings.ToValidUTF8(s, "\uFFFD")
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func junitSummaryProperties(s domain.Summary) []JUnitProperty {
+	d := s.Durations
+	return []JUnitProperty{
+		{Name: "success_rate", Value: fmt.Sprintf("%.2f", s.SuccessRate)},
+		{Name: "timed_out", Value: fmt.Sprint(s.TimedOut)},
+		{Name: "hook_failures", Value: fmt.Sprint(s.HookFailed)},
+		{Name: "throughput_per_sec", Value: fmt.Sprintf("%.3f", s.Throughput)},
+		{Name: "duration_mean_ms", Value: fmt.Sprintf("%.3f", durationMs(d.Mean))},
+		{Name: "duration_stddev_ms", Value: fmt.Sprintf("%.3f", durationMs(d.StdDev))},
+		{Name: "duration_min_ms", Value: fmt.Sprintf("%.3f", durationMs(d.Min))},
+		{Name: "duration_max_ms", Value: fmt.Sprintf("%.3f", durationMs(d.Max))},
+		{Name: "duration_p50_ms", Value: fmt.Sprintf("%.3f", durationMs(d.P50))},
+		{Name: "duration_p90_ms", Value: fmt.Sprintf("%.3f", durationMs(d.P90))},
+		{Name: "duration_p95_ms", Value: fmt.Sprintf("%.3f", durationMs(d.P95))},
+		{Name: "duration_p99_ms", Value: fmt.Sprintf("%.3f", durationMs(d.P99))},
+		{Name: "cpu_user_mean_ms", Value: fmt.Sprintf("%.3f", durationMs(s.Resources.UserTime.Mean))},
+		{Name: "cpu_system_mean_ms", Value: fmt.Sprintf("%.3f", durationMs(s.Resources.SystemTime.Mean))},
+		{Name: "max_rss_peak_bytes", Value: fmt.Sprint(s.Resources.MaxRSSPeak)},
+	}
+}
+
+func junitBaselineProperties(check *domain.BaselineCheck) []JUnitProperty {
+	if check == nil {
+		return nil
+	}
+	return []JUnitProperty{
+		{Name: "baseline_verdict", Value: string(check.Verdict)},
+		{Name: "baseline_change_pct", Value: fmt.Sprintf("%.2f", check.Change)},
+		{Name: "baseline_p_value", Value: fmt.Sprintf("%.4f", check.PValue)},
+		{Name: "baseline_mean_ms", Value: fmt.Sprintf("%.3f", durationMs(check.Previous.Mean))},
+	}
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,188 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/msaeedsaeedi/again/internal/domain"
+)
+
+const (
+	eventRunStarted   = "run_started"
+	eventRunCompleted = "run_completed"
+	eventSummary      = "summary"
+	eventHookStarted  = "hook_started"
+	eventHookComplete = "hook_completed"
+	eventWarmupStart  = "warmup_started"
+	eventWarmupDone   = "warmup_completed"
+)
+
+type RunStartedEventJSON struct {
+	Event     string    `json:"event"`
+	ID        int       `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type RunCompletedEventJSON struct {
+	Event     string    `json:"event"`
+	Timestamp time.Time `json:"timestamp"`
+	ResultJSON
+}
+
+// HookEventJSON reports hook progress; ID is 0 for setup and teardown.
+type HookEventJSON struct {
+	Event     string    `json:"event"`
+	Timestamp time.Time `json:"timestamp"`
+	ID        int       `json:"id"`
+	Phase     string    `json:"phase"`
+	*HookJSON
+}
+
+type SummaryEventJSON struct {
+	Event     string    `json:"event"`
+	Timestamp time.Time `json:"timestamp"`
+	SummaryJSON
+	Comparison *ComparisonJSON `json:"comparison,omitempty"`
+	Baseline   *BaselineJSON   `json:"baseline,omitempty"`
+	Tests      []TestStatsJSON `json:"tests,omitempty"`
+	// FailureClusters groups failed runs by failure mode, largest first
+	FailureClusters []FailureClusterJSON `json:"failure_clusters,omitempty"`
+}
+
+// NDJSONFormatter streams one JSON event per line as runs progress.
+type NDJSONFormatter struct {
+	config  *domain.RunConfig
+	encoder *json.Encoder
+	stats   *domain.StatsCollector
+	compare *domain.ComparisonCollector
+	tests   *domain.TestCollector
+	faults  *domain.FailureClusterer
+	check   *domain.BaselineCheck
+	mu      sync.Mutex
+}
+
+func NewNDJSONFormatter(cfg *domain.RunConfig) *NDJSONFormatter {
+	return &NDJSONFormatter{
+		config:  cfg,
+		encoder: json.NewEncoder(os.Stdout),
+		stats:   domain.NewStatsCollector(),
+		compare: domain.NewComparisonCollector(cfg),
+		tests:   domain.NewTestCollector(),
+		faults:  domain.NewFailureClusterer(),
+	}
+}
+
+func (f *NDJSONFormatter) GetOutputWriters(runID int) (stdout, stderr io.Writer) {
+	return nil, nil
+}
+
+func (f *NDJSONFormatter) OnStart(runID int) {
+	f.emit(RunStartedEventJSON{
+		Event:     eventRunStarted,
+		ID:        runID,
+		Timestamp: time.Now(),
+	})
+}
+
+func (f *NDJSONFormatter) OnComplete(result domain.RunResult) {
+	f.mu.Lock()
+	f.stats.Add(result)
+	f.compare.Add(result)
+	f.tests.Add(result)
+	f.faults.Add(result)
+	f.mu.Unlock()
+
+	f.emit(RunCompletedEventJSON{
+		Event:      eventRunCompleted,
+		Timestamp:  time.Now(),
+		ResultJSON: newResultJSON(result),
+	})
+}
+
+func (f *NDJSONFormatter) OnHookStart(runID int, phase domain.HookPhase) {
+	f.emit(HookEventJSON{
+		Event:     eventHookStarted,
+		Timestamp: time.Now(),
+		ID:        runID,
+		Phase:     string(phase),
+	})
+}
+
+func (f *NDJSONFormatter) OnHookComplete(runID int, result domain.HookResult) {
+	hook := newHookJSON(result)
+	f.emit(HookEventJSON{
+		Event:     eventHookComplete,
+		Timestamp: time.Now(),
+		ID:        runID,
+		Phase:     hook.Phase,
+		HookJSON:  &hook,
+	})
+}
+
+// Warmup events use the same shapes as run events and are only emitted with --include-warmup.
+func (f *NDJSONFormatter) OnWarmupStart(n int) {
+	if !f.config.IncludeWarmup {
+		return
+	}
+	f.emit(RunStartedEventJSON{
+		Event:     eventWarmupStart,
+		ID:        n,
+		Timestamp: time.Now(),
+	})
+}
+
+func (f *NDJSONFormatter) OnWarmupComplete(result domain.RunResult) {
+	if !f.config.IncludeWarmup {
+		return
+	}
+	f.emit(RunCompletedEventJSON{
+		Event:      eventWarmupDone,
+		Timestamp:  time.Now(),
+		ResultJSON: newResultJSON(result),
+	})
+}
+
+func (f *NDJSONFormatter) GetWarmupWriters(n int) (stdout, stderr io.Writer) {
+	return nil, nil
+}
+
+// OnBaselineCheck keeps the verdict for the summary event.
+func (f *NDJSONFormatter) OnBaselineCheck(check domain.BaselineCheck) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.check = &check
+}
+
+func (f *NDJSONFormatter) OnFinish() {
+	f.mu.Lock()
+	summary := f.stats.Summary()
+	cmp := f.compare.Comparison()
+	tests := f.tests.Stats()
+	clusters := f.faults.Clusters()
+	check := f.check
+	f.mu.Unlock()
+
+	f.emit(SummaryEventJSON{
+		Event:           eventSummary,
+		Timestamp:       time.Now(),
+		SummaryJSON:     newSummaryJSON(summary),
+		Comparison:      newComparisonJSON(f.config, cmp),
+		Baseline:        newBaselineJSON(f.config, check),
+		Tests:           newTestStatsJSON(tests),
+		FailureClusters: newFailureClustersJSON(clusters),
+	})
+}
+
+func (f *NDJSONFormatter) emit(event any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.encoder.Encode(event); err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding NDJSON event: %v\n", err)
+		os.Exit(1)
+	}
+}
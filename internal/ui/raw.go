@@ -1,42 +1,238 @@
 package ui
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
+	"strings"
+	"sync"
 
 	"github.com/msaeedsaeedi/again/internal/domain"
 )
 
-type RawFormatter struct{}
+type RawFormatter struct {
+	config  *domain.RunConfig
+	mu      sync.Mutex
+	writers map[int][]*prefixWriter
+	warmups map[int][]*prefixWriter
+	stats   *domain.StatsCollector
+	compare *domain.ComparisonCollector
+	tests   *domain.TestCollector
+	faults  *domain.FailureClusterer
+	check   *domain.BaselineCheck
+}
+
+func NewRawFormatter(cfg *domain.RunConfig) *RawFormatter {
+	return &RawFormatter{
+		config:  cfg,
+		writers: make(map[int][]*prefixWriter),
+		warmups: make(map[int][]*prefixWriter),
+		stats:   domain.NewStatsCollector(),
+		compare: domain.NewComparisonCollector(cfg),
+		tests:   domain.NewTestCollector(),
+		faults:  domain.NewFailureClusterer(),
+	}
+}
 
-func NewRawFormatter() *RawFormatter {
-	return &RawFormatter{}
+func (f *RawFormatter) GetOutputWriters(runID int) (stdout, stderr io.Writer) {
+	return f.outputWriters(f.writers, fmt.Sprintf("[%d] ", runID), runID)
 }
 
-func (f *RawFormatter) GetOutputWriters() (stdout, stderr io.Writer) {
-	return os.Stdout, os.Stderr
+// outputWriters passes output straight through, or prefixes it when runs are concurrent.
+func (f *RawFormatter) outputWriters(registry map[int][]*prefixWriter, prefix string, id int) (stdout, stderr io.Writer) {
+	if f.config.Parallel <= 1 {
+		return os.Stdout, os.Stderr
+	}
+
+	// Concurrent runs write line-by-line with a run prefix so interleaved output stays attributable
+	out := &prefixWriter{mu: &f.mu, out: os.Stdout, prefix: prefix}
+	errOut := &prefixWriter{mu: &f.mu, out: os.Stderr, prefix: prefix}
+
+	f.mu.Lock()
+	registry[id] = []*prefixWriter{out, errOut}
+	f.mu.Unlock()
+
+	return out, errOut
+}
+
+// flushWriters writes out partial lines left by a finished run.
+func (f *RawFormatter) flushWriters(registry map[int][]*prefixWriter, id int) {
+	f.mu.Lock()
+	writers := registry[id]
+	delete(registry, id)
+	f.mu.Unlock()
+
+	for _, w := range writers {
+		w.Flush()
+	}
+}
+
+// iterationLabel names an iteration, adding the command it ran when comparing.
+func iterationLabel(kind string, id, commandIndex int) string {
+	if commandIndex > 0 {
+		return fmt.Sprintf("%s %d of command %d", kind, id, commandIndex)
+	}
+	return fmt.Sprintf("%s %d", kind, id)
 }
 
 func (f *RawFormatter) OnStart(runID int) {
-	fmt.Fprintf(os.Stderr, "[ Run %d ]\n", runID)
+	fmt.Fprintf(os.Stderr, "[ %s ]\n", iterationLabel("Run", runID, f.config.CommandIndex(runID)))
 }
 
 func (f *RawFormatter) OnComplete(result domain.RunResult) {
-	fmt.Fprintf(os.Stderr, "[ Run %d completed in %v", result.ID, result.Duration)
+	f.flushWriters(f.writers, result.ID)
 
-	if !result.Success {
-		if result.Error != nil {
-			fmt.Fprintf(os.Stderr, " - FAILED: exit code %d, error: %v", result.ExitCode, result.Error)
-		} else {
-			fmt.Fprintf(os.Stderr, " - FAILED: exit code %d", result.ExitCode)
+	line := f.resultLine(iterationLabel("Run", result.ID, result.CommandIndex), result)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stats.Add(result)
+	f.compare.Add(result)
+	f.tests.Add(result)
+	f.faults.Add(result)
+	io.WriteString(os.Stderr, line)
+}
+
+func (f *RawFormatter) OnWarmupStart(n int) {
+	fmt.Fprintf(os.Stderr, "[ %s ]\n", iterationLabel("Warmup", n, f.config.WarmupCommandIndex(n)))
+}
+
+func (f *RawFormatter) OnWarmupComplete(result domain.RunResult) {
+	f.flushWriters(f.warmups, result.ID)
+
+	line := f.resultLine(iterationLabel("Warmup", result.ID, result.CommandIndex), result)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	io.WriteString(os.Stderr, line)
+}
+
+func (f *RawFormatter) GetWarmupWriters(n int) (stdout, stderr io.Writer) {
+	return f.outputWriters(f.warmups, fmt.Sprintf("[w%d] ", n), n)
+}
+
+func (f *RawFormatter) resultLine(label string, result domain.RunResult) string {
+	var sb strings.Builder
+	switch {
+	case result.Status == domain.StatusSkipped:
+		fmt.Fprintf(&sb, "[ %s - SKIPPED", label)
+	case result.Success:
+		fmt.Fprintf(&sb, "[ %s completed in %v - SUCCESS", label, result.Duration)
+		if result.Reason != "" {
+			fmt.Fprintf(&sb, " (%s)", result.Reason)
 		}
-	} else {
-		fmt.Fprintf(os.Stderr, " - SUCCESS")
+	default:
+		fmt.Fprintf(&sb, "[ %s completed in %v - %s", label, result.Duration, f.failureLabel(result))
+	}
+	if len(result.Tests) > 0 {
+		counts := result.TestCounts()
+		fmt.Fprintf(&sb, ", tests: %d passed, %d failed, %d skipped", counts.Passed, counts.Failed, counts.Skipped)
+	}
+	sb.WriteString(" ]\n")
+	return sb.String()
+}
+
+func (f *RawFormatter) failureLabel(result domain.RunResult) string {
+	switch result.Failure {
+	case domain.FailureTimeout:
+		return fmt.Sprintf("TIMEOUT: exceeded %v", f.config.Timeout)
+	case domain.FailureSignaled:
+		return "SIGNALED: " + result.FailureDescription()
+	case domain.FailureCancelled:
+		return "CANCELLED"
+	case domain.FailureStartError:
+		return fmt.Sprintf("START ERROR: %v", result.Error)
+	default:
+		return "FAILED: " + result.FailureDescription()
+	}
+}
+
+func (f *RawFormatter) OnHookStart(runID int, phase domain.HookPhase) {
+	if runID == 0 {
+		fmt.Fprintf(os.Stderr, "[ %s ]\n", capitalize(string(phase)))
+	}
+}
+
+func (f *RawFormatter) OnHookComplete(runID int, result domain.HookResult) {
+	// Setup and teardown output is not followed by an OnComplete that would flush it
+	if runID == 0 {
+		f.flushWriters(f.writers, 0)
+	}
+
+	label := capitalize(string(result.Phase))
+	if runID != 0 {
+		label = fmt.Sprintf("Run %d %s hook", runID, result.Phase)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case !result.Success:
+		fmt.Fprintf(os.Stderr, "[ %s FAILED: %s ]\n", label, result.Reason)
+	case runID == 0:
+		fmt.Fprintf(os.Stderr, "[ %s completed in %v ]\n", label, result.Duration)
 	}
-	fmt.Println(" ]")
 }
 
 func (f *RawFormatter) OnFinish() {
-	// No-op
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	writeSummary(os.Stderr, f.stats.Summary())
+	if f.config.Comparing() {
+		writeComparison(os.Stderr, f.compare.Comparison())
+	}
+	if f.check != nil {
+		writeBaseline(os.Stderr, f.config.Baseline, *f.check)
+	}
+	if f.config.Parser != domain.ParserNone {
+		writeTests(os.Stderr, f.tests.Stats())
+	}
+	if clusters := f.faults.Clusters(); len(clusters) > 0 {
+		writeFailures(os.Stderr, clusters)
+	}
+}
+
+func (f *RawFormatter) OnBaselineCheck(check domain.BaselineCheck) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.check = &check
+}
+
+// prefixWriter buffers partial lines and writes complete ones with a prefix.
+type prefixWriter struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.writeLine(w.buf[:i+1])
+		w.buf = w.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush writes any trailing output that did not end with a newline.
+func (w *prefixWriter) Flush() {
+	if len(w.buf) == 0 {
+		return
+	}
+	w.writeLine(append(w.buf, '\n'))
+	w.buf = nil
+}
+
+func (w *prefixWriter) writeLine(line []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fmt.Fprintf(w.out, "%s%s", w.prefix, line)
 }
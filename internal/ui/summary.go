@@ -0,0 +1,269 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/msaeedsaeedi/again/internal/domain"
+)
+
+// roundDuration trims durations to a readable precision for display.
+func roundDuration(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(time.Microsecond)
+	default:
+		return d
+	}
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// writeSummary renders a plain-text statistics block.
+func writeSummary(w io.Writer, s domain.Summary) {
+	d := s.Durations
+
+	fmt.Fprintln(w, "[ Summary ]")
+	fmt.Fprintf(w, "  Runs:        %d executed (%d passed, %d failed, %d timed out, %d skipped)\n",
+		s.Executed, s.Succeeded, s.Failed, s.TimedOut, s.Skipped)
+	fmt.Fprintf(w, "  Success:     %.1f%%\n", s.SuccessRate)
+	if s.HookFailed > 0 {
+		fmt.Fprintf(w, "  Hooks:       %d runs with failed hooks\n", s.HookFailed)
+	}
+
+	if d.Count == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "  Duration:    mean %v ± %v, min %v, max %v\n",
+		roundDuration(d.Mean), roundDuration(d.StdDev), roundDuration(d.Min), roundDuration(d.Max))
+	fmt.Fprintf(w, "  Percentiles: p50 %v, p90 %v, p95 %v, p99 %v\n",
+		roundDuration(d.P50), roundDuration(d.P90), roundDuration(d.P95), roundDuration(d.P99))
+	fmt.Fprintf(w, "  Throughput:  %.2f runs/s over %v\n", s.Throughput, roundDuration(s.WallTime))
+
+	r := s.Resources
+	fmt.Fprintf(w, "  CPU time:    user mean %v (max %v), system mean %v (max %v)\n",
+		roundDuration(r.UserTime.Mean), roundDuration(r.UserTime.Max), roundDuration(r.SystemTime.Mean), roundDuration(r.SystemTime.Max))
+	fmt.Fprintf(w, "  Max RSS:     mean %s, peak %s\n", formatBytes(r.MaxRSSMean), formatBytes(r.MaxRSSPeak))
+}
+
+// commandLabel shortens a compared command to fit a table column.
+func commandLabel(command []string, width int) string {
+	return truncate(strings.Join(command, " "), width)
+}
+
+// truncate shortens s to at most width runes, marking the cut with an ellipsis.
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// comparisonTable lays out one row per compared command, starting with a header row.
+func comparisonTable(cmp domain.Comparison) [][]string {
+	rows := [][]string{{"#", "Command", "Runs", "Mean ± σ", fmt.Sprintf("%.0f%% CI", domain.ConfidenceLevel*100), "Range", "Relative"}}
+	for _, c := range cmp.Commands {
+		d := c.Summary.Durations
+		row := []string{fmt.Sprint(c.Index), commandLabel(c.Command, 32), fmt.Sprintf("%d (%.0f%%)", c.Summary.Executed, c.Summary.SuccessRate)}
+		if d.Count == 0 {
+			rows = append(rows, append(row, "-", "-", "-", "-"))
+			continue
+		}
+
+		relative := fmt.Sprintf("%.2f ± %.2f", c.Relative, c.RelativeStdDev)
+		if c.Index == cmp.Fastest {
+			relative = "1.00 (fastest)"
+		}
+		rows = append(rows, append(row,
+			fmt.Sprintf("%v ± %v", roundDuration(d.Mean), roundDuration(d.StdDev)),
+			fmt.Sprintf("%v … %v", roundDuration(c.MeanCI.Low), roundDuration(c.MeanCI.High)),
+			fmt.Sprintf("%v … %v", roundDuration(d.Min), roundDuration(d.Max)),
+			relative))
+	}
+	return rows
+}
+
+// formatTable pads every column to its widest cell.
+func formatTable(rows [][]string) []string {
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+
+	lines := make([]string, 0, len(rows))
+	for _, row := range rows {
+		var sb strings.Builder
+		for i, cell := range row {
+			if i > 0 {
+				sb.WriteString("   ")
+			}
+			sb.WriteString(cell)
+			if i < len(row)-1 {
+				sb.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)))
+			}
+		}
+		lines = append(lines, sb.String())
+	}
+	return lines
+}
+
+// comparisonVerdicts states how much faster the fastest command was than each of the others.
+func comparisonVerdicts(cmp domain.Comparison) []string {
+	fastest, ok := cmp.FastestStats()
+	if !ok {
+		return nil
+	}
+
+	var verdicts []string
+	for _, c := range cmp.Commands {
+		if c.Index == fastest.Index || c.Summary.Durations.Count == 0 {
+			continue
+		}
+		verdicts = append(verdicts, fmt.Sprintf("%s ran %.2f ± %.2f times faster than %s",
+			commandLabel(fastest.Command, 32), c.Relative, c.RelativeStdDev, commandLabel(c.Command, 32)))
+	}
+	return verdicts
+}
+
+// writeComparison renders a plain-text comparison of the benchmarked commands.
+func writeComparison(w io.Writer, cmp domain.Comparison) {
+	fmt.Fprintln(w, "[ Comparison ]")
+	for _, line := range formatTable(comparisonTable(cmp)) {
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+	for _, verdict := range comparisonVerdicts(cmp) {
+		fmt.Fprintf(w, "  %s\n", verdict)
+	}
+}
+
+// baselineLine describes a baseline check in one line, such as
+// "regression: mean 12ms vs 10ms (+20.0%, threshold 5%, p=0.0003)".
+func baselineLine(check domain.BaselineCheck) string {
+	if check.Verdict == domain.VerdictInconclusive {
+		return fmt.Sprintf("inconclusive: at least 2 measured runs are needed on both sides (%d now, %d in baseline)",
+			check.Current.Count, check.Previous.Count)
+	}
+	return fmt.Sprintf("%s: mean %v vs %v (%+.1f%%, threshold %g%%, p=%.4f)", check.Verdict,
+		roundDuration(check.Current.Mean), roundDuration(check.Previous.Mean), check.Change, check.Threshold, check.PValue)
+}
+
+// writeBaseline renders a plain-text baseline check.
+func writeBaseline(w io.Writer, path string, check domain.BaselineCheck) {
+	fmt.Fprintln(w, "[ Baseline ]")
+	fmt.Fprintf(w, "  File:        %s (%d runs, %s)\n", path, check.Previous.Count, check.Baseline.CreatedAt.Format(time.RFC3339))
+	fmt.Fprintf(w, "  Success:     %.1f%% (baseline %.1f%%)\n", check.SuccessRate, check.Baseline.SuccessRate)
+	fmt.Fprintf(w, "  Verdict:     %s\n", baselineLine(check))
+}
+
+// testName qualifies a test with its package, as in "example.com/pkg.TestName".
+func testName(pkg, name string) string {
+	switch {
+	case pkg == "":
+		return name
+	case name == domain.PackageTest:
+		return pkg + " " + name
+	default:
+		return pkg + "." + name
+	}
+}
+
+// testsTable lays out one row per test that failed at least once, starting with a header row.
+func testsTable(stats []domain.TestStats) [][]string {
+	rows := [][]string{{"Test", "Runs", "Passed", "Failed", "Skipped", "Failure rate"}}
+	for _, s := range stats {
+		if s.Failed == 0 {
+			continue
+		}
+		name := testName(s.Package, s.Name)
+		if s.Flaky() {
+			name += " (flaky)"
+		}
+		rows = append(rows, []string{name, fmt.Sprint(s.Runs), fmt.Sprint(s.Passed), fmt.Sprint(s.Failed),
+			fmt.Sprint(s.Skipped), fmt.Sprintf("%.1f%%", s.FailureRate)})
+	}
+	return rows
+}
+
+// writeTests renders a plain-text flakiness report of the parsed test results.
+func writeTests(w io.Writer, stats []domain.TestStats) {
+	rows := testsTable(stats)
+	fmt.Fprintln(w, "[ Tests ]")
+	if len(rows) > 1 {
+		for _, line := range formatTable(rows) {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+	fmt.Fprintf(w, "  %d of %d tests failed in at least one run\n", len(rows)-1, len(stats))
+}
+
+// failureClusterLine describes a failure cluster in one line, such as
+// "12 runs (32.4%), exit-nonzero, e.g. run 17 [1a2b3c4d]".
+// failureModesLine tells how many distinct failure modes the failed runs fall into.
+func failureModesLine(modes, failed int) string {
+	modeWord, runWord := "modes", "runs"
+	if modes == 1 {
+		modeWord = "mode"
+	}
+	if failed == 1 {
+		runWord = "run"
+	}
+	return fmt.Sprintf("%d distinct failure %s in %d failed %s", modes, modeWord, failed, runWord)
+}
+
+func failureClusterLine(c domain.FailureCluster, failed int) string {
+	runs := "runs"
+	if c.Count() == 1 {
+		runs = "run"
+	}
+	return fmt.Sprintf("%d %s (%.1f%%), %s, e.g. run %d [%s]",
+		c.Count(), runs, float64(c.Count())*100/float64(max(1, failed)), c.Failure, c.Example, c.ID)
+}
+
+// failedRuns counts the runs across all clusters.
+func failedRuns(clusters []domain.FailureCluster) int {
+	failed := 0
+	for _, c := range clusters {
+		failed += c.Count()
+	}
+	return failed
+}
+
+// writeFailures renders the failure clusters with the output tail of an example run each.
+func writeFailures(w io.Writer, clusters []domain.FailureCluster) {
+	failed := failedRuns(clusters)
+	fmt.Fprintln(w, "[ Failures ]")
+	fmt.Fprintf(w, "  %s\n", failureModesLine(len(clusters), failed))
+	for i, c := range clusters {
+		fmt.Fprintf(w, "  #%d  %s\n", i+1, failureClusterLine(c, failed))
+		for _, line := range c.Output {
+			fmt.Fprintf(w, "        %s\n", line)
+		}
+	}
+}
@@ -5,9 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -46,16 +46,29 @@ var (
 )
 
 type TUIFormatter struct {
-	model   *Model
-	program *tea.Program
-	runID   int64
-	ready   chan struct{}
-	once    sync.Once
+	model    *Model
+	program  *tea.Program
+	ready    chan struct{}
+	once     sync.Once
+	mu       sync.Mutex
+	warnings []error // Printed once the TUI has exited, as the alternate screen would hide them
 }
 
 type startMsg struct{ runID int }
 type completeMsg struct{ result domain.RunResult }
 type allCompleteMsg struct{}
+type scheduledMsg struct{ at time.Time }
+type baselineMsg struct{ check domain.BaselineCheck }
+type warmupStartMsg struct{ n int }
+type warmupCompleteMsg struct{ result domain.RunResult }
+type hookStartMsg struct {
+	runID int
+	phase domain.HookPhase
+}
+type hookCompleteMsg struct {
+	runID  int
+	result domain.HookResult
+}
 type tickMsg time.Time
 
 type streamMsg struct {
@@ -72,17 +85,42 @@ type tuiWriter struct {
 }
 
 type runState struct {
-	id         int
-	status     string // "pending", "running", "success", "failed"
+	id         int      // Negative for warmup iterations
+	command    int      // 1-based index of the compared command, 0 when not comparing
+	status     string   // "pending", "running", or a domain.RunStatus
+	expanded   []string // Expanded command, known once the run completes
 	exitCode   int
+	failure    domain.FailureKind
+	signal     string
+	reason     string           // Failure description for the details panel
+	verdict    string           // Why custom success criteria accepted or rejected the run
+	artifacts  string           // Directory holding the run's persisted output
+	phase      domain.HookPhase // Hook currently running, empty while the command runs
+	hooks      []domain.HookResult
 	duration   time.Duration
 	startedAt  time.Time
 	finishedAt time.Time
+	usage      domain.ResourceUsage
+	tests      domain.TestCounts
+	parsed     bool // Whether test results were parsed from the run's output
+	evicted    bool // Whether the run's logs were dropped to bound memory
 }
 
+type viewMode int
+
+const (
+	viewDetails viewMode = iota
+	viewStats
+	viewCompare
+	viewTests
+	viewFailures
+	viewDiff
+)
+
 type logLine struct {
 	timestamp time.Time
 	text      string // Pre-styled text with timestamp
+	raw       string // Unstyled output line, compared when diffing runs
 	isErr     bool
 }
 
@@ -90,37 +128,74 @@ type Model struct {
 	cfg                 *domain.RunConfig
 	started             int
 	completed           int
+	skipped             int
+	warmupsDone         int
 	finished            bool
 	quit                bool
 	width               int
 	height              int
 	runs                []runState
+	runIndex            map[int]int // Position of each run in runs, by ID
 	selectedRun         int
 	runLogs             map[int][]logLine // Per-run log storage
 	maxLinesPerRun      int               // Max lines per individual run
+	maxFinishedLines    int               // Max lines kept once a run has finished
+	maxFinishedLogs     int               // Max finished runs whose logs are kept
+	finishedLogs        []int             // IDs of finished runs with logs, oldest first
 	scrollOffset        int               // Vertical scroll for log view
 	sidebarScrollOffset int               // Vertical scroll for sidebar
 	autoScroll          bool              // Auto-scroll to latest logs
 	lastTickTime        time.Time         // Last tick time for consistent duration calculation
+	sessionStart        time.Time         // First run start, used for the time budget countdown
+	nextStart           time.Time         // Next paced run start, zero when unpaced
+	sessionPhase        domain.HookPhase  // Setup or teardown while it runs
+	sessionHooks        []domain.HookResult
+	stats               *domain.StatsCollector
+	compare             *domain.ComparisonCollector
+	tests               *domain.TestCollector
+	faults              *domain.FailureClusterer
+	marked              []int                 // IDs of up to two runs marked for diffing, oldest first
+	diffOffset          int                   // Vertical scroll of the diff view
+	baseline            *domain.BaselineCheck // Set once the runs were checked against --compare
+	view                viewMode
 	mu                  sync.Mutex
 }
 
 func NewModel(cfg *domain.RunConfig) *Model {
-	runs := make([]runState, cfg.Times)
-	for i := 0; i < cfg.Times; i++ {
-		runs[i] = runState{
-			id:     i + 1,
-			status: "pending",
+	// Warmups come first; open-ended sessions grow the run list as iterations start
+	runs := make([]runState, 0, cfg.TotalWarmups())
+	for n := 1; n <= cfg.TotalWarmups(); n++ {
+		runs = append(runs, runState{id: -n, command: cfg.WarmupCommandIndex(n), status: "pending"})
+	}
+	if !cfg.OpenEnded() {
+		for i := 0; i < cfg.TotalRuns(); i++ {
+			runs = append(runs, runState{
+				id:      i + 1,
+				command: cfg.CommandIndex(i + 1),
+				status:  "pending",
+			})
 		}
 	}
 
+	runIndex := make(map[int]int, len(runs))
+	for i, run := range runs {
+		runIndex[run.id] = i
+	}
+
 	return &Model{
-		cfg:            cfg,
-		runLogs:        make(map[int][]logLine),
-		maxLinesPerRun: 10000,
-		runs:           runs,
-		selectedRun:    0,
-		autoScroll:     true,
+		cfg:              cfg,
+		runLogs:          make(map[int][]logLine),
+		maxLinesPerRun:   10000,
+		maxFinishedLines: 1000,
+		maxFinishedLogs:  200,
+		runs:             runs,
+		runIndex:         runIndex,
+		selectedRun:      0,
+		autoScroll:       true,
+		stats:            domain.NewStatsCollector(),
+		compare:          domain.NewComparisonCollector(cfg),
+		tests:            domain.NewTestCollector(),
+		faults:           domain.NewFailureClusterer(),
 	}
 }
 
@@ -143,37 +218,75 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case startMsg:
 		m.mu.Lock()
 		m.started++
-		for i := range m.runs {
-			if m.runs[i].id == msg.runID {
-				m.runs[i].status = "running"
-				m.runs[i].startedAt = time.Now()
-				break
-			}
+		if m.sessionStart.IsZero() {
+			m.sessionStart = time.Now()
 		}
+		m.findRun(msg.runID).start()
+		m.mu.Unlock()
+
+	case warmupStartMsg:
+		m.mu.Lock()
+		m.findRun(-msg.n).start()
+		m.mu.Unlock()
+
+	case warmupCompleteMsg:
+		m.mu.Lock()
+		m.warmupsDone++
+		m.findRun(-msg.result.ID).complete(msg.result)
+		m.retireLogs(-msg.result.ID)
 		m.mu.Unlock()
 
 	case completeMsg:
 		m.mu.Lock()
-		m.completed++
-		for i := range m.runs {
-			if m.runs[i].id == msg.result.ID {
-				if msg.result.Success {
-					m.runs[i].status = "success"
-				} else {
-					m.runs[i].status = "failed"
-				}
-				m.runs[i].exitCode = msg.result.ExitCode
-				m.runs[i].duration = msg.result.Duration
-				m.runs[i].finishedAt = msg.result.FinishedAt
-				break
-			}
+		if msg.result.Status == domain.StatusSkipped {
+			m.skipped++
+		} else {
+			m.completed++
 		}
+		m.stats.Add(msg.result)
+		m.compare.Add(msg.result)
+		m.tests.Add(msg.result)
+		m.faults.Add(msg.result)
+		m.findRun(msg.result.ID).complete(msg.result)
+		m.retireLogs(msg.result.ID)
+		m.mu.Unlock()
+
+	case hookStartMsg:
+		m.mu.Lock()
+		if msg.runID == 0 {
+			m.sessionPhase = msg.phase
+		} else {
+			m.findRun(msg.runID).phase = msg.phase
+		}
+		m.mu.Unlock()
+
+	case hookCompleteMsg:
+		m.mu.Lock()
+		if msg.runID == 0 {
+			m.sessionPhase = ""
+			m.sessionHooks = append(m.sessionHooks, msg.result)
+		} else {
+			run := m.findRun(msg.runID)
+			run.phase = ""
+			run.hooks = append(run.hooks, msg.result)
+		}
+		m.mu.Unlock()
+
+	case baselineMsg:
+		m.mu.Lock()
+		m.baseline = &msg.check
 		m.mu.Unlock()
 
 	case streamMsg:
 		m.appendLog(msg)
 		return m, nil
 
+	case scheduledMsg:
+		m.mu.Lock()
+		m.nextStart = msg.at
+		m.mu.Unlock()
+		return m, nil
+
 	case tickMsg:
 		m.mu.Lock()
 		m.lastTickTime = time.Time(msg)
@@ -215,23 +328,84 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.mu.Unlock()
 		case "home":
 			m.mu.Lock()
-			m.scrollOffset = 0
-			m.autoScroll = false
+			if m.view == viewDiff {
+				m.diffOffset = 0
+			} else {
+				m.scrollOffset = 0
+				m.autoScroll = false
+			}
 			m.mu.Unlock()
 		case "end":
 			m.mu.Lock()
-			m.scrollOffset = 999999
-			m.autoScroll = true
+			if m.view == viewDiff {
+				m.diffOffset = 999999
+			} else {
+				m.scrollOffset = 999999
+				m.autoScroll = true
+			}
 			m.mu.Unlock()
 		case "pgup":
 			m.mu.Lock()
-			m.scrollOffset = max(0, m.scrollOffset-10)
-			m.autoScroll = false
+			if m.view == viewDiff {
+				m.diffOffset = max(0, m.diffOffset-10)
+			} else {
+				m.scrollOffset = max(0, m.scrollOffset-10)
+				m.autoScroll = false
+			}
 			m.mu.Unlock()
 		case "pgdown":
 			m.mu.Lock()
-			m.scrollOffset += 10
-			m.autoScroll = false
+			if m.view == viewDiff {
+				m.diffOffset += 10
+			} else {
+				m.scrollOffset += 10
+				m.autoScroll = false
+			}
+			m.mu.Unlock()
+		case "s":
+			m.mu.Lock()
+			if m.view == viewStats {
+				m.view = viewDetails
+			} else {
+				m.view = viewStats
+			}
+			m.mu.Unlock()
+		case "c":
+			m.mu.Lock()
+			if m.view == viewCompare {
+				m.view = viewDetails
+			} else if m.cfg.Comparing() {
+				m.view = viewCompare
+			}
+			m.mu.Unlock()
+		case "t":
+			m.mu.Lock()
+			if m.view == viewTests {
+				m.view = viewDetails
+			} else if m.cfg.Parser != domain.ParserNone {
+				m.view = viewTests
+			}
+			m.mu.Unlock()
+		case "m":
+			m.mu.Lock()
+			m.toggleMark()
+			m.mu.Unlock()
+		case "d":
+			m.mu.Lock()
+			if m.view == viewDiff {
+				m.view = viewDetails
+			} else if len(m.marked) == 2 {
+				m.view = viewDiff
+				m.diffOffset = 0
+			}
+			m.mu.Unlock()
+		case "f":
+			m.mu.Lock()
+			if m.view == viewFailures {
+				m.view = viewDetails
+			} else {
+				m.view = viewFailures
+			}
 			m.mu.Unlock()
 		}
 
@@ -245,6 +419,111 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// toggleMark marks the selected run for diffing, or unmarks it. Marking a third
+// run replaces the oldest mark, and marking the second opens the diff view.
+func (m *Model) toggleMark() {
+	if m.selectedRun >= len(m.runs) {
+		return
+	}
+	id := m.runs[m.selectedRun].id
+
+	if i := slices.Index(m.marked, id); i >= 0 {
+		m.marked = slices.Delete(m.marked, i, i+1)
+		if m.view == viewDiff {
+			m.view = viewDetails
+		}
+		return
+	}
+
+	m.marked = append(m.marked, id)
+	if len(m.marked) > 2 {
+		m.marked = m.marked[1:]
+	}
+	if len(m.marked) == 2 {
+		m.view = viewDiff
+		m.diffOffset = 0
+	}
+}
+
+func (r *runState) start() {
+	r.status = "running"
+	r.startedAt = time.Now()
+}
+
+func (r *runState) complete(result domain.RunResult) {
+	r.status = string(result.Status)
+	r.expanded = result.Command
+	r.exitCode = result.ExitCode
+	r.failure = result.Failure
+	r.signal = result.Signal
+	r.reason = result.FailureDescription()
+	r.verdict = ""
+	// Hook failures already explain themselves in the status line
+	if result.Failure != domain.FailureHook {
+		r.verdict = result.Reason
+	}
+	r.artifacts = result.ArtifactDir
+	r.duration = result.Duration
+	r.finishedAt = result.FinishedAt
+	r.usage = result.Usage
+	r.phase = ""
+	r.hooks = result.Hooks
+	r.tests = result.TestCounts()
+	r.parsed = len(result.Tests) > 0
+}
+
+func (r runState) warmup() bool {
+	return r.id < 0
+}
+
+// label names the row, numbering warmups separately from measured runs
+// and tagging the compared command.
+func (r runState) label() string {
+	label := fmt.Sprintf("Run #%03d", r.id)
+	if r.warmup() {
+		label = fmt.Sprintf("Warm #%03d", -r.id)
+	}
+	if r.command > 0 {
+		label += fmt.Sprintf(" [%d]", r.command)
+	}
+	return label
+}
+
+// findRun returns the state for id, appending it when the session is open-ended.
+func (m *Model) findRun(id int) *runState {
+	if i, ok := m.runIndex[id]; ok {
+		return &m.runs[i]
+	}
+	m.runIndex[id] = len(m.runs)
+	m.runs = append(m.runs, runState{id: id, status: "pending"})
+	return &m.runs[len(m.runs)-1]
+}
+
+// retireLogs trims the logs of a finished run to their tail and drops the logs of the
+// oldest finished runs beyond maxFinishedLogs, so that long sessions do not grow without
+// bound. Runs marked for diffing keep their logs; --artifacts keeps every run's output.
+func (m *Model) retireLogs(id int) {
+	logs := m.runLogs[id]
+	if len(logs) == 0 {
+		return
+	}
+	if len(logs) > m.maxFinishedLines {
+		m.runLogs[id] = slices.Clone(logs[len(logs)-m.maxFinishedLines:])
+	}
+	m.finishedLogs = append(m.finishedLogs, id)
+
+	for i := 0; len(m.finishedLogs) > m.maxFinishedLogs && i < len(m.finishedLogs); {
+		old := m.finishedLogs[i]
+		if slices.Contains(m.marked, old) {
+			i++
+			continue
+		}
+		delete(m.runLogs, old)
+		m.findRun(old).evicted = true
+		m.finishedLogs = slices.Delete(m.finishedLogs, i, i+1)
+	}
+}
+
 func (m *Model) View() string {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -261,7 +540,21 @@ func (m *Model) View() string {
 	contentH := max(10, availHeight-footerHeight)
 
 	sidebar := m.renderSidebar(sidebarW, contentH)
-	mainPanel := m.renderMainPanel(mainW, contentH)
+	var mainPanel string
+	switch m.view {
+	case viewStats:
+		mainPanel = m.renderStatsPanel(mainW, contentH)
+	case viewCompare:
+		mainPanel = m.renderComparisonPanel(mainW, contentH)
+	case viewTests:
+		mainPanel = m.renderTestsPanel(mainW, contentH)
+	case viewFailures:
+		mainPanel = m.renderFailuresPanel(mainW, contentH)
+	case viewDiff:
+		mainPanel = m.renderDiffPanel(mainW, contentH)
+	default:
+		mainPanel = m.renderMainPanel(mainW, contentH)
+	}
 	footer := m.renderFooter(availWidth)
 
 	body := lipgloss.JoinHorizontal(lipgloss.Top, sidebar, mainPanel)
@@ -312,7 +605,12 @@ func (m *Model) renderRunLine(index int) string {
 		timeStr = run.startedAt.Format("15:04:05")
 	}
 
-	rowLeft := fmt.Sprintf("Run #%03d %s %-2s", run.id, icon, statusStr)
+	rowLeft := fmt.Sprintf("%s %s %-2s", run.label(), icon, statusStr)
+
+	// Warmups are greyed out since they do not count towards results
+	if run.warmup() {
+		runStyle = stylePending
+	}
 
 	var line string
 	if index == m.selectedRun {
@@ -321,7 +619,7 @@ func (m *Model) renderRunLine(index int) string {
 		} else {
 			line = fmt.Sprintf("┃ %s", rowLeft)
 		}
-		return styleActive.Render(line)
+		return styleActive.Render(line + m.markLabel(run.id))
 	}
 
 	if timeStr != "" {
@@ -329,7 +627,19 @@ func (m *Model) renderRunLine(index int) string {
 	} else {
 		line = fmt.Sprintf("  %s", rowLeft)
 	}
-	return runStyle.Render(line)
+	return runStyle.Render(line + m.markLabel(run.id))
+}
+
+// markLabel tags runs marked for diffing with the side of the diff they are on.
+func (m *Model) markLabel(id int) string {
+	switch slices.Index(m.marked, id) {
+	case 0:
+		return " ◆ a"
+	case 1:
+		return " ◆ b"
+	default:
+		return ""
+	}
 }
 
 func (m *Model) getRunStatusDisplay(run runState) (icon, statusStr string, style lipgloss.Style) {
@@ -337,9 +647,32 @@ func (m *Model) getRunStatusDisplay(run runState) (icon, statusStr string, style
 	case "success":
 		return "✓", "0", styleSuccess
 	case "failed":
-		return "✗", fmt.Sprintf("%d", run.exitCode), styleFailure
+		switch run.failure {
+		case domain.FailureSignaled:
+			return "✗", strings.TrimPrefix(run.signal, "SIG"), styleFailure
+		case domain.FailureHook:
+			return "!", "hook", styleFailure
+		case domain.FailureStartError:
+			return "!", "start", styleFailure
+		case domain.FailureCancelled:
+			return "⊘", "", styleFailure
+		case domain.FailureAssertion:
+			return "✗", "out", styleFailure
+		default:
+			return "✗", fmt.Sprintf("%d", run.exitCode), styleFailure
+		}
 	case "running":
+		switch run.phase {
+		case domain.HookBeforeEach:
+			return "...", "pre", styleRunning
+		case domain.HookAfterEach:
+			return "...", "post", styleRunning
+		}
 		return "...", "", styleRunning
+	case "skipped":
+		return "⊘", "", stylePending
+	case "timeout":
+		return "⧗", "", styleFailure
 	default:
 		return "-", "", stylePending
 	}
@@ -354,20 +687,33 @@ func (m *Model) renderMainPanel(width, height int) string {
 
 	run := m.runs[m.selectedRun]
 
-	main.WriteString(styleBoldWhite.Render(fmt.Sprintf("RUN DETAILS: #%03d", run.id)))
+	title := fmt.Sprintf("RUN DETAILS: #%03d", run.id)
+	if run.warmup() {
+		title = fmt.Sprintf("WARMUP DETAILS: #%03d (excluded from results)", -run.id)
+	}
+	if run.command > 0 {
+		title += fmt.Sprintf(" - COMMAND %d OF %d", run.command, len(m.cfg.Commands))
+	}
+	main.WriteString(styleBoldWhite.Render(title))
 	main.WriteString("\n\n")
 
-	m.renderCommandSection(&main)
+	m.renderCommandSection(&main, run)
 	m.renderStatusSection(&main, run)
 	m.renderDurationSection(&main, run)
+	m.renderResourcesSection(&main, run)
 	m.renderLogsSection(&main, run, height)
 
 	return styleMain.Width(width).Height(height).Render(main.String())
 }
 
-func (m *Model) renderCommandSection(w *strings.Builder) {
+func (m *Model) renderCommandSection(w *strings.Builder, run runState) {
+	command := m.cfg.CommandFor(run.command)
+	if len(run.expanded) > 0 {
+		command = run.expanded
+	}
+
 	w.WriteString(styleBoldWhite.Render("Command"))
-	fmt.Fprintf(w, "\n  > %s\n\n", strings.Join(m.cfg.Command, " "))
+	fmt.Fprintf(w, "\n  > %s\n\n", strings.Join(command, " "))
 }
 
 func (m *Model) renderStatusSection(w *strings.Builder, run runState) {
@@ -378,14 +724,43 @@ func (m *Model) renderStatusSection(w *strings.Builder, run runState) {
 	case "success":
 		statText = styleSuccess.Render("Success (Exit Code: 0)")
 	case "failed":
-		statText = styleFailure.Render(fmt.Sprintf("Failed (Exit Code: %d)", run.exitCode))
+		switch run.failure {
+		case domain.FailureExitNonZero, domain.FailureNone:
+			statText = styleFailure.Render(fmt.Sprintf("Failed (Exit Code: %d)", run.exitCode))
+		default:
+			statText = styleFailure.Render(capitalize(run.reason))
+		}
 	case "running":
-		statText = styleRunning.Render("Running...")
+		if run.phase != "" {
+			statText = styleRunning.Render(fmt.Sprintf("Running %s hook...", run.phase))
+		} else {
+			statText = styleRunning.Render("Running...")
+		}
+	case "skipped":
+		statText = stylePending.Render("Skipped (execution stopped early)")
+	case "timeout":
+		statText = styleFailure.Render(fmt.Sprintf("Timed out (after %s)", m.cfg.Timeout))
 	default:
 		statText = "Pending"
 	}
 
-	w.WriteString("  " + statText + "\n\n")
+	w.WriteString("  " + statText + "\n")
+
+	// Always render the verdict line to maintain consistent height
+	var notes []string
+	if run.verdict != "" {
+		notes = append(notes, run.verdict)
+	}
+	if run.parsed {
+		notes = append(notes, fmt.Sprintf("Tests: %d passed, %d failed, %d skipped", run.tests.Passed, run.tests.Failed, run.tests.Skipped))
+	}
+	if run.artifacts != "" {
+		notes = append(notes, "Artifacts: "+run.artifacts)
+	}
+	if len(notes) > 0 {
+		w.WriteString("  " + styleDim.Render(strings.Join(notes, "   ")))
+	}
+	w.WriteString("\n\n")
 }
 
 func (m *Model) renderDurationSection(w *strings.Builder, run runState) {
@@ -404,10 +779,30 @@ func (m *Model) renderDurationSection(w *strings.Builder, run runState) {
 
 	w.WriteString(styleBoldWhite.Render("Duration") + "\n")
 	if dur > 0 {
-		w.WriteString(fmt.Sprintf("  %s\n\n", dur.Round(time.Millisecond)))
+		w.WriteString(fmt.Sprintf("  %s", dur.Round(time.Millisecond)))
 	} else {
+		w.WriteString("  -")
+	}
+
+	// Hook timings share the line; they are not part of the measured duration
+	if len(run.hooks) > 0 {
+		w.WriteString("   " + hooksLabel(run.hooks))
+	}
+	w.WriteString("\n\n")
+}
+
+func (m *Model) renderResourcesSection(w *strings.Builder, run runState) {
+	// Always render Resources section to maintain consistent height
+	w.WriteString(styleBoldWhite.Render("Resources") + "\n")
+	if run.usage == (domain.ResourceUsage{}) {
 		w.WriteString("  -\n\n")
+		return
 	}
+
+	u := run.usage
+	fmt.Fprintf(w, "  CPU user %s, sys %s   Max RSS %s   Ctx switches %d vol / %d invol\n\n",
+		roundDuration(u.UserTime), roundDuration(u.SystemTime), formatBytes(u.MaxRSS),
+		u.VoluntaryCtxSwitches, u.InvoluntaryCtxSwitches)
 }
 
 func (m *Model) renderLogsSection(w *strings.Builder, run runState, contentHeight int) {
@@ -419,9 +814,12 @@ func (m *Model) renderLogsSection(w *strings.Builder, run runState, contentHeigh
 	for _, entry := range runLogEntries {
 		runLogs = append(runLogs, entry.text)
 	}
+	if run.evicted {
+		runLogs = []string{styleDim.Render(fmt.Sprintf("Output dropped, only the last %d finished runs keep theirs", m.maxFinishedLogs))}
+	}
 
-	// Reserve lines: title(1) + blank(1) + header sections(~12) + scroll indicator(1)
-	logAreaHeight := max(5, contentHeight-15)
+	// Reserve lines: title(1) + blank(1) + header sections(~16) + scroll indicator(1)
+	logAreaHeight := max(5, contentHeight-19)
 	totalLogLines := len(runLogs)
 
 	if m.autoScroll && totalLogLines > logAreaHeight {
@@ -452,17 +850,331 @@ func (m *Model) renderLogsSection(w *strings.Builder, run runState, contentHeigh
 	}
 }
 
+func (m *Model) renderStatsPanel(width, height int) string {
+	var w strings.Builder
+	s := m.stats.Summary()
+	d := s.Durations
+
+	w.WriteString(styleBoldWhite.Render("STATISTICS"))
+	w.WriteString("\n\n")
+
+	w.WriteString(styleBoldWhite.Render("Runs") + "\n")
+	fmt.Fprintf(&w, "  %d executed   %s   %s   %s   %s\n\n",
+		s.Executed,
+		styleSuccess.Render(fmt.Sprintf("%d passed", s.Succeeded)),
+		styleFailure.Render(fmt.Sprintf("%d failed", s.Failed)),
+		styleFailure.Render(fmt.Sprintf("%d timed out", s.TimedOut)),
+		stylePending.Render(fmt.Sprintf("%d skipped", s.Skipped)))
+
+	w.WriteString(styleBoldWhite.Render("Success Rate") + "\n")
+	if s.Executed > 0 {
+		fmt.Fprintf(&w, "  %.1f%%\n\n", s.SuccessRate)
+	} else {
+		w.WriteString("  -\n\n")
+	}
+
+	w.WriteString(styleBoldWhite.Render("Duration") + "\n")
+	if d.Count > 0 {
+		fmt.Fprintf(&w, "  %-8s %v\n", "Mean", roundDuration(d.Mean))
+		fmt.Fprintf(&w, "  %-8s %v\n", "StdDev", roundDuration(d.StdDev))
+		fmt.Fprintf(&w, "  %-8s %v\n", "Min", roundDuration(d.Min))
+		fmt.Fprintf(&w, "  %-8s %v\n\n", "Max", roundDuration(d.Max))
+	} else {
+		w.WriteString("  -\n\n")
+	}
+
+	w.WriteString(styleBoldWhite.Render("Percentiles") + "\n")
+	if d.Count > 0 {
+		fmt.Fprintf(&w, "  %-8s %v\n", "p50", roundDuration(d.P50))
+		fmt.Fprintf(&w, "  %-8s %v\n", "p90", roundDuration(d.P90))
+		fmt.Fprintf(&w, "  %-8s %v\n", "p95", roundDuration(d.P95))
+		fmt.Fprintf(&w, "  %-8s %v\n\n", "p99", roundDuration(d.P99))
+	} else {
+		w.WriteString("  -\n\n")
+	}
+
+	w.WriteString(styleBoldWhite.Render("Throughput") + "\n")
+	if s.Throughput > 0 {
+		fmt.Fprintf(&w, "  %.2f runs/s\n\n", s.Throughput)
+	} else {
+		w.WriteString("  -\n\n")
+	}
+
+	r := s.Resources
+	w.WriteString(styleBoldWhite.Render("Resources") + "\n")
+	if d.Count > 0 {
+		fmt.Fprintf(&w, "  %-8s mean %v, max %v\n", "CPU user", roundDuration(r.UserTime.Mean), roundDuration(r.UserTime.Max))
+		fmt.Fprintf(&w, "  %-8s mean %v, max %v\n", "CPU sys", roundDuration(r.SystemTime.Mean), roundDuration(r.SystemTime.Max))
+		fmt.Fprintf(&w, "  %-8s mean %s, peak %s\n", "Max RSS", formatBytes(r.MaxRSSMean), formatBytes(r.MaxRSSPeak))
+	} else {
+		w.WriteString("  -\n")
+	}
+
+	if m.cfg.Baseline != "" {
+		w.WriteString("\n" + styleBoldWhite.Render("Baseline") + "\n")
+		fmt.Fprintf(&w, "  %s\n", styleDim.Render(m.cfg.Baseline))
+		switch {
+		case m.baseline == nil:
+			fmt.Fprintf(&w, "  %s\n", stylePending.Render("checked once all runs have finished"))
+		case m.baseline.Regressed():
+			fmt.Fprintf(&w, "  %s\n", styleFailure.Render(baselineLine(*m.baseline)))
+		case m.baseline.Verdict == domain.VerdictImprovement:
+			fmt.Fprintf(&w, "  %s\n", styleSuccess.Render(baselineLine(*m.baseline)))
+		default:
+			fmt.Fprintf(&w, "  %s\n", baselineLine(*m.baseline))
+		}
+	}
+
+	if m.hasHooks() {
+		w.WriteString("\n" + styleBoldWhite.Render("Hooks") + "\n")
+		for _, hook := range m.sessionHooks {
+			fmt.Fprintf(&w, "  %s\n", hookLabel(hook))
+		}
+		if s.HookFailed > 0 {
+			fmt.Fprintf(&w, "  %s\n", styleFailure.Render(fmt.Sprintf("%d runs with failed hooks", s.HookFailed)))
+		} else {
+			fmt.Fprintf(&w, "  %s\n", styleDim.Render("no per-run hook failures"))
+		}
+	}
+
+	return styleMain.Width(width).Height(height).Render(w.String())
+}
+
+func (m *Model) renderComparisonPanel(width, height int) string {
+	var w strings.Builder
+	cmp := m.compare.Comparison()
+
+	w.WriteString(styleBoldWhite.Render("COMPARISON"))
+	w.WriteString("\n\n")
+
+	order := "each command's runs in turn"
+	if m.cfg.Interleave {
+		order = "interleaved"
+	}
+	w.WriteString(styleDim.Render(fmt.Sprintf("%d runs per command, %s, %.0f%% confidence intervals of the mean",
+		m.cfg.Times, order, domain.ConfidenceLevel*100)))
+	w.WriteString("\n\n")
+
+	for i, line := range formatTable(comparisonTable(cmp)) {
+		switch {
+		case i == 0:
+			w.WriteString("  " + styleBoldWhite.Render(line))
+		case i == cmp.Fastest:
+			w.WriteString("  " + styleSuccess.Render(line))
+		default:
+			w.WriteString("  " + line)
+		}
+		w.WriteString("\n")
+	}
+
+	w.WriteString("\n" + styleBoldWhite.Render("Summary") + "\n")
+	verdicts := comparisonVerdicts(cmp)
+	if len(verdicts) == 0 {
+		w.WriteString("  -\n")
+	}
+	for _, verdict := range verdicts {
+		fmt.Fprintf(&w, "  %s\n", verdict)
+	}
+
+	return styleMain.Width(width).Height(height).Render(w.String())
+}
+
+func (m *Model) renderTestsPanel(width, height int) string {
+	var w strings.Builder
+	stats := m.tests.Stats()
+	rows := testsTable(stats)
+
+	w.WriteString(styleBoldWhite.Render("TESTS"))
+	w.WriteString("\n\n")
+	w.WriteString(styleDim.Render(fmt.Sprintf("%d tests parsed from %d runs, %d failed in at least one run",
+		len(stats), m.completed, len(rows)-1)))
+	w.WriteString("\n\n")
+
+	if len(rows) == 1 {
+		w.WriteString("  " + styleSuccess.Render("No test has failed yet") + "\n")
+		return styleMain.Width(width).Height(height).Render(w.String())
+	}
+
+	// Rows follow the failing tests in order, so the stats line up with the table
+	visible := max(1, height-6)
+	for i, line := range formatTable(rows) {
+		if i > visible {
+			fmt.Fprintf(&w, "  %s\n", styleDim.Render(fmt.Sprintf("… %d more", len(rows)-i)))
+			break
+		}
+		switch {
+		case i == 0:
+			w.WriteString("  " + styleBoldWhite.Render(line))
+		case stats[i-1].Flaky():
+			w.WriteString("  " + styleRunning.Render(line))
+		default:
+			w.WriteString("  " + styleFailure.Render(line))
+		}
+		w.WriteString("\n")
+	}
+
+	return styleMain.Width(width).Height(height).Render(w.String())
+}
+
+// renderDiffPanel shows a unified diff of the captured logs of the two marked
+// runs, with timestamps masked so that only real differences stand out.
+func (m *Model) renderDiffPanel(width, height int) string {
+	var w strings.Builder
+	oldRun, newRun := *m.findRun(m.marked[0]), *m.findRun(m.marked[1])
+
+	w.WriteString(styleBoldWhite.Render(fmt.Sprintf("DIFF: %s → %s", oldRun.label(), newRun.label())))
+	w.WriteString("\n\n")
+
+	for _, run := range []runState{oldRun, newRun} {
+		if run.evicted {
+			w.WriteString("  " + styleFailure.Render(fmt.Sprintf("Output of %s was dropped, only the last %d finished runs keep theirs", run.label(), m.maxFinishedLogs)) + "\n")
+			return styleMain.Width(width).Height(height).Render(w.String())
+		}
+	}
+
+	hunks := domain.UnifiedDiff(m.diffableLogs(oldRun.id), m.diffableLogs(newRun.id), domain.DiffContext)
+	if len(hunks) == 0 {
+		w.WriteString("  " + styleSuccess.Render("No differences in output (timestamps ignored)") + "\n")
+		return styleMain.Width(width).Height(height).Render(w.String())
+	}
+
+	var lines []string
+	lineWidth := max(10, width-6)
+	for _, hunk := range hunks {
+		lines = append(lines, styleActive.Render(hunk.Header()))
+		for _, line := range hunk.Lines {
+			text := truncate(string(line.Kind)+line.Text, lineWidth)
+			switch line.Kind {
+			case domain.DiffDelete:
+				lines = append(lines, styleFailure.Render(text))
+			case domain.DiffInsert:
+				lines = append(lines, styleSuccess.Render(text))
+			default:
+				lines = append(lines, styleDim.Render(text))
+			}
+		}
+	}
+
+	visible := max(1, height-4)
+	m.diffOffset = max(0, min(m.diffOffset, len(lines)-visible))
+	end := min(len(lines), m.diffOffset+visible)
+	for _, line := range lines[m.diffOffset:end] {
+		w.WriteString("  " + line + "\n")
+	}
+	if end < len(lines) {
+		w.WriteString(styleDim.Render("... (scroll down for more) ..."))
+	}
+
+	return styleMain.Width(width).Height(height).Render(w.String())
+}
+
+// diffableLogs returns a run's captured log lines with timestamps masked.
+func (m *Model) diffableLogs(id int) []string {
+	entries := m.runLogs[id]
+	lines := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		lines = append(lines, domain.StripTimestamps(entry.raw))
+	}
+	return lines
+}
+
+// renderFailuresPanel lists the failure clusters, largest first, with the
+// output tail of an example run each, as far as the panel has room.
+func (m *Model) renderFailuresPanel(width, height int) string {
+	var w strings.Builder
+	clusters := m.faults.Clusters()
+	failed := failedRuns(clusters)
+
+	w.WriteString(styleBoldWhite.Render("FAILURES"))
+	w.WriteString("\n\n")
+
+	if len(clusters) == 0 {
+		w.WriteString("  " + styleSuccess.Render("No run has failed yet") + "\n")
+		return styleMain.Width(width).Height(height).Render(w.String())
+	}
+
+	w.WriteString(styleDim.Render(failureModesLine(len(clusters), failed) + ", grouped by normalized output"))
+	w.WriteString("\n\n")
+
+	lines := 4
+	lineWidth := max(10, width-12)
+	for i, c := range clusters {
+		if lines+1 > height-1 {
+			fmt.Fprintf(&w, "  %s\n", styleDim.Render(fmt.Sprintf("… %d more", len(clusters)-i)))
+			break
+		}
+		fmt.Fprintf(&w, "  %s %s\n", styleBoldWhite.Render(fmt.Sprintf("#%d", i+1)), styleFailure.Render(failureClusterLine(c, failed)))
+		lines++
+		for _, line := range c.Output {
+			if lines >= height-2 {
+				break
+			}
+			fmt.Fprintf(&w, "      %s\n", styleDim.Render(truncate(line, lineWidth)))
+			lines++
+		}
+		w.WriteString("\n")
+		lines++
+	}
+
+	return styleMain.Width(width).Height(height).Render(w.String())
+}
+
 func (m *Model) renderFooter(width int) string {
-	progressStr := fmt.Sprintf("%d/%d", m.completed, m.cfg.Times)
+	progressStr := fmt.Sprintf("%d/%d", m.completed, m.cfg.TotalRuns())
+	if m.cfg.OpenEnded() {
+		parts := []string{fmt.Sprintf("%d runs", m.completed)}
+		if m.cfg.Times > 0 {
+			parts[0] = fmt.Sprintf("%d/%d max", m.completed, m.cfg.Times)
+		}
+		if label := untilLabel(m.cfg); label != "" {
+			parts = append(parts, label)
+		}
+		if m.cfg.TimeBudget > 0 && !m.finished {
+			parts = append(parts, m.timeRemainingLabel())
+		}
+		progressStr = strings.Join(parts, ", ")
+	}
 	stateStr := "Active"
-	if m.finished {
+	if m.warmupsDone < m.cfg.TotalWarmups() && m.started == 0 && !m.finished {
+		stateStr = fmt.Sprintf("Warming up %d/%d", m.warmupsDone, m.cfg.TotalWarmups())
+	}
+	if m.sessionPhase != "" {
+		stateStr = capitalize(string(m.sessionPhase)) + " running"
+	} else if failed := m.failedSessionHook(); failed != "" {
+		stateStr = capitalize(string(failed)) + " failed"
+	} else if m.baseline != nil && m.baseline.Regressed() {
+		stateStr = fmt.Sprintf("Regression vs baseline (%+.1f%%)", m.baseline.Change)
+	} else if m.skipped > 0 {
+		stateStr = fmt.Sprintf("Stopped (%d skipped)", m.skipped)
+	} else if m.finished {
 		stateStr = "Complete"
 	}
+	if !m.finished && !m.nextStart.IsZero() {
+		now := m.lastTickTime
+		if now.IsZero() {
+			now = time.Now()
+		}
+		if wait := m.nextStart.Sub(now); wait > 0 {
+			stateStr += fmt.Sprintf(", next start in %s", wait.Round(100*time.Millisecond))
+		}
+	}
 	leftSection := styleHelpText.Render(progressStr + " " + stateStr)
 
 	var helpItems []string
 	helpItems = append(helpItems, styleHelpKey.Render("↑/k")+styleHelpText.Render(" navigate"))
 	helpItems = append(helpItems, styleHelpKey.Render("pgup/pgdn")+styleHelpText.Render(" scroll"))
+	helpItems = append(helpItems, styleHelpKey.Render("s")+styleHelpText.Render(" stats"))
+	if m.cfg.Comparing() {
+		helpItems = append(helpItems, styleHelpKey.Render("c")+styleHelpText.Render(" compare"))
+	}
+	if m.cfg.Parser != domain.ParserNone {
+		helpItems = append(helpItems, styleHelpKey.Render("t")+styleHelpText.Render(" tests"))
+	}
+	helpItems = append(helpItems, styleHelpKey.Render("f")+styleHelpText.Render(" failures"))
+	if len(m.marked) == 2 {
+		helpItems = append(helpItems, styleHelpKey.Render("d")+styleHelpText.Render(" diff"))
+	}
+	helpItems = append(helpItems, styleHelpKey.Render("m")+styleHelpText.Render(" mark"))
 	helpItems = append(helpItems, styleHelpKey.Render("q")+styleHelpText.Render(" quit"))
 
 	rightSection := strings.Join(helpItems, "   ")
@@ -477,6 +1189,75 @@ func (m *Model) renderFooter(width int) string {
 	return styleFooter.Width(width).Render(footerLine)
 }
 
+func (m *Model) hasHooks() bool {
+	for range m.cfg.HookCommands() {
+		return true
+	}
+	return false
+}
+
+func (m *Model) failedSessionHook() domain.HookPhase {
+	for _, hook := range m.sessionHooks {
+		if !hook.Success {
+			return hook.Phase
+		}
+	}
+	return ""
+}
+
+func (m *Model) timeRemainingLabel() string {
+	if m.sessionStart.IsZero() {
+		return fmt.Sprintf("%s left", m.cfg.TimeBudget)
+	}
+
+	now := m.lastTickTime
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	remaining := m.cfg.TimeBudget - now.Sub(m.sessionStart)
+	if remaining <= 0 {
+		return "time up, finishing"
+	}
+	return fmt.Sprintf("%s left", remaining.Round(time.Second))
+}
+
+// hooksLabel lists hook timings, highlighting failed hooks.
+func hooksLabel(hooks []domain.HookResult) string {
+	parts := make([]string, 0, len(hooks))
+	for _, hook := range hooks {
+		parts = append(parts, hookLabel(hook))
+	}
+	return styleDim.Render("hooks: ") + strings.Join(parts, styleDim.Render(", "))
+}
+
+func hookLabel(hook domain.HookResult) string {
+	if hook.Success {
+		return styleDim.Render(fmt.Sprintf("%s %s", hook.Phase, roundDuration(hook.Duration)))
+	}
+	return styleFailure.Render(fmt.Sprintf("%s failed: %s", hook.Phase, hook.Reason))
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func untilLabel(cfg *domain.RunConfig) string {
+	switch cfg.Until {
+	case domain.UntilFail:
+		return "until fail"
+	case domain.UntilSuccess:
+		return "until success"
+	case domain.UntilStreak:
+		return fmt.Sprintf("until %d in a row", cfg.Streak)
+	default:
+		return ""
+	}
+}
+
 func (m *Model) appendLog(msg streamMsg) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -503,6 +1284,7 @@ func (m *Model) appendLog(msg streamMsg) {
 		entry := logLine{
 			timestamp: timestamp,
 			text:      ts + styledLine,
+			raw:       line,
 			isErr:     msg.isErr,
 		}
 
@@ -532,6 +1314,21 @@ func (f *TUIFormatter) Run(ctx context.Context) error {
 	return nil
 }
 
+func (f *TUIFormatter) OnWarning(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.warnings = append(f.warnings, err)
+}
+
+// WriteWarnings writes the warnings received while the TUI was shown.
+func (f *TUIFormatter) WriteWarnings(w io.Writer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, warning := range f.warnings {
+		fmt.Fprintln(w, "Warning:", warning)
+	}
+}
+
 func (f *TUIFormatter) WaitReady(ctx context.Context) error {
 	select {
 	case <-f.ready:
@@ -542,7 +1339,6 @@ func (f *TUIFormatter) WaitReady(ctx context.Context) error {
 }
 
 func (f *TUIFormatter) OnStart(runID int) {
-	atomic.StoreInt64(&f.runID, int64(runID))
 	if f.program != nil {
 		f.program.Send(startMsg{runID: runID})
 	}
@@ -560,10 +1356,50 @@ func (f *TUIFormatter) OnFinish() {
 	}
 }
 
-func (f *TUIFormatter) GetOutputWriters() (stdout, stderr io.Writer) {
-	id := int(atomic.LoadInt64(&f.runID))
-	return &tuiWriter{program: f.program, isErr: false, formatter: f, runID: id},
-		&tuiWriter{program: f.program, isErr: true, formatter: f, runID: id}
+func (f *TUIFormatter) OnScheduled(at time.Time) {
+	if f.program != nil {
+		f.program.Send(scheduledMsg{at: at})
+	}
+}
+
+func (f *TUIFormatter) OnHookStart(runID int, phase domain.HookPhase) {
+	if f.program != nil {
+		f.program.Send(hookStartMsg{runID: runID, phase: phase})
+	}
+}
+
+func (f *TUIFormatter) OnHookComplete(runID int, result domain.HookResult) {
+	if f.program != nil {
+		f.program.Send(hookCompleteMsg{runID: runID, result: result})
+	}
+}
+
+func (f *TUIFormatter) OnBaselineCheck(check domain.BaselineCheck) {
+	if f.program != nil {
+		f.program.Send(baselineMsg{check: check})
+	}
+}
+
+func (f *TUIFormatter) OnWarmupStart(n int) {
+	if f.program != nil {
+		f.program.Send(warmupStartMsg{n: n})
+	}
+}
+
+func (f *TUIFormatter) OnWarmupComplete(result domain.RunResult) {
+	if f.program != nil {
+		f.program.Send(warmupCompleteMsg{result: result})
+	}
+}
+
+// GetWarmupWriters stores warmup output under the warmup's negative row ID.
+func (f *TUIFormatter) GetWarmupWriters(n int) (stdout, stderr io.Writer) {
+	return f.GetOutputWriters(-n)
+}
+
+func (f *TUIFormatter) GetOutputWriters(runID int) (stdout, stderr io.Writer) {
+	return &tuiWriter{program: f.program, isErr: false, formatter: f, runID: runID},
+		&tuiWriter{program: f.program, isErr: true, formatter: f, runID: runID}
 }
 
 func (w *tuiWriter) Write(p []byte) (int, error) {